@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Airport is an entry in the bundled airport database, airports.json in the
+// resources folder.
+type Airport struct {
+	IATA      string  `json:"iata,omitempty"`
+	Name      string  `json:"name"`
+	Lat       float64 `json:"lat"`
+	Lon       float64 `json:"lon"`
+	Elevation int     `json:"elevation"`
+	Country   string  `json:"country"`
+}
+
+// loadAirports reads the airport database at path in the resources
+// folder, keyed by ICAO code. Without the file, no airports are known.
+func loadAirports(path string) (map[string]Airport, error) {
+	airports := make(map[string]Airport)
+	entries, err := loadJSONResource[map[string]Airport](path, true)
+	if err != nil {
+		return airports, err
+	}
+	for icao, ap := range entries {
+		airports[strings.ToUpper(icao)] = ap
+	}
+	return airports, nil
+}
+
+// resolveAirports returns the ICAO codes of the airports to fetch, which may
+// be given by their IATA codes instead.
+func resolveAirports(codes []string, airports map[string]Airport) (resolved, unknown []string, err error) {
+	for _, code := range codes {
+		icao, known, err := resolveAirport(code, airports)
+		if err != nil {
+			return nil, nil, err
+		}
+		if slices.Contains(resolved, icao) {
+			continue
+		}
+		resolved = append(resolved, icao)
+		if !known {
+			unknown = append(unknown, icao)
+		}
+	}
+	return resolved, unknown, nil
+}
+
+func resolveAirport(code string, airports map[string]Airport) (icao string, known bool, err error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if _, ok := airports[code]; ok {
+		return code, true, nil
+	}
+	switch {
+	case len(code) == 3 && strings.Trim(code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") == "":
+		for _, icao := range sortedKeys(airports) {
+			if airports[icao].IATA == code {
+				return icao, true, nil
+			}
+		}
+		return "", false, fmt.Errorf("%v: unknown IATA code. Use the ICAO code, or add the airport to %v",
+			code, resourceName("airports.json"))
+	case icaoCode(code):
+		return code, false, nil
+	default:
+		return "", false, fmt.Errorf("%q isn't an ICAO or IATA airport code", code)
+	}
+}
+
+// icaoCode reports whether code looks like an ICAO airport code: four
+// letters or digits, starting with a letter.
+func icaoCode(code string) bool {
+	if len(code) != 4 || code[0] < 'A' || code[0] > 'Z' {
+		return false
+	}
+	for _, ch := range code {
+		if !(ch >= 'A' && ch <= 'Z') && !(ch >= '0' && ch <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// coord returns the [lon, lat] location of the airport with code icao,
+// preferring the one FlightAware gave (fa) and falling back to the database.
+func coord(airports map[string]Airport, icao string, fa []float64) []float64 {
+	if len(fa) == 2 {
+		return fa
+	}
+	if ap, ok := airports[icao]; ok {
+		return []float64{ap.Lon, ap.Lat}
+	}
+	return nil
+}
+
+// initialBearing returns the true course in degrees from the first point
+// to the second along the great circle between them.
+func initialBearing(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := math.Pi / 180
+	phi1, phi2 := lat1*rad, lat2*rad
+	dl := (lon2 - lon1) * rad
+	y := math.Sin(dl) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dl)
+	return math.Mod(math.Atan2(y, x)/rad+360, 360)
+}
+
+// originBearing returns the bearing in degrees true from airport to origin,
+// which is the direction a flight from origin arrives from, if both are in
+// airports.
+func originBearing(airports map[string]Airport, airport, origin string) (float64, bool) {
+	o, ok := airports[origin]
+	if !ok {
+		return 0, false
+	}
+	a, ok := airports[airport]
+	if !ok {
+		return 0, false
+	}
+	return initialBearing(a.Lat, a.Lon, o.Lat, o.Lon), true
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestResolveAirport(t *testing.T) {
+	airports := map[string]Airport{
+		"KJFK": {IATA: "JFK"},
+		"EGLL": {IATA: "LHR"},
+		"KTEB": {},
+		"K1A2": {},
+	}
+	tests := []struct {
+		code  string
+		icao  string
+		known bool
+		ok    bool
+	}{
+		{"KJFK", "KJFK", true, true},
+		{"kjfk", "KJFK", true, true},
+		{" EGLL ", "EGLL", true, true},
+		{"K1A2", "K1A2", true, true},
+		// IATA codes.
+		{"JFK", "KJFK", true, true},
+		{"lhr", "EGLL", true, true},
+		{"TEB", "", false, false},
+		{"XXX", "", false, false},
+		// ICAO-shaped codes that aren't in the database.
+		{"KXYZ", "KXYZ", false, true},
+		{"K0B8", "K0B8", false, true},
+		// Neither.
+		{"", "", false, false},
+		{"JF", "", false, false},
+		{"1JFK", "", false, false},
+		{"KJFKX", "", false, false},
+		{"K-FK", "", false, false},
+		{"J1K", "", false, false},
+	}
+	for _, test := range tests {
+		icao, known, err := resolveAirport(test.code, airports)
+		if icao != test.icao || known != test.known || (err == nil) != test.ok {
+			t.Errorf("resolveAirport(%q) = %q, %v, %v; want %q, %v, ok %v", test.code, icao, known, err,
+				test.icao, test.known, test.ok)
+		}
+	}
+}
+
+func TestResolveAirports(t *testing.T) {
+	airports := map[string]Airport{"KJFK": {IATA: "JFK"}, "KLGA": {IATA: "LGA"}}
+
+	resolved, unknown, err := resolveAirports([]string{"JFK", "KLGA", "KJFK", "KXYZ", "kxyz"}, airports)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"KJFK", "KLGA", "KXYZ"}; !slices.Equal(resolved, want) {
+		t.Errorf("resolved %v; want %v", resolved, want)
+	}
+	if want := []string{"KXYZ"}; !slices.Equal(unknown, want) {
+		t.Errorf("unknown %v; want %v", unknown, want)
+	}
+
+	if _, _, err := resolveAirports([]string{"KJFK", "XXX"}, airports); err == nil {
+		t.Error("an unknown IATA code was accepted")
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Altitude check modes, for Config.AltitudeCheck.
+const (
+	altitudeCheckOff     = ""
+	altitudeCheckFlag    = "flag"
+	altitudeCheckCorrect = "correct"
+)
+
+// validAltitudeCheck returns an error if mode isn't an altitude check mode.
+func validAltitudeCheck(mode string) error {
+	switch mode {
+	case altitudeCheckOff, altitudeCheckFlag, altitudeCheckCorrect:
+		return nil
+	default:
+		return fmt.Errorf("%q is not a valid altitude check; use \"flag\" or \"correct\"", mode)
+	}
+}
+
+// Filed altitudes below minCruiseAltitude or above maxCruiseAltitude feet
+// are nonsense as far as vice is concerned.
+const (
+	minCruiseAltitude = 1000
+	maxCruiseAltitude = 60000
+	hemisphericFloor  = 3000
+)
+
+// The direction-of-flight rules use magnetic course but only true course is
+// known, so within magneticMargin degrees of north or south an altitude is
+// flagged but not corrected.
+const magneticMargin = 10
+
+// parseAltitude returns the cruise altitude in feet from a FlightAware
+// flight plan altitude.
+func parseAltitude(v any) (int, error) {
+	var alt float64
+	switch a := v.(type) {
+	case nil:
+		return 0, fmt.Errorf("no altitude")
+	case float64:
+		alt = a
+	case string:
+		s := strings.ToUpper(strings.TrimSpace(a))
+		if lo, _, ok := strings.Cut(s, "B"); ok && lo != "" {
+			s = lo
+		}
+		s = strings.TrimSuffix(strings.TrimSuffix(s, "FT"), "'")
+		s = strings.TrimPrefix(strings.TrimPrefix(s, "FL"), "F")
+		s = strings.TrimPrefix(s, "A")
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q isn't an altitude", a)
+		}
+		alt = f
+	default:
+		return 0, fmt.Errorf("%v isn't an altitude", v)
+	}
+	if alt < 1000 {
+		alt *= 100
+	}
+	if alt <= 0 || alt > maxCruiseAltitude {
+		return 0, fmt.Errorf("%v isn't a plausible altitude", v)
+	}
+	return int(math.Round(alt)), nil
+}
+
+// hemisphericAltitude checks alt against the direction-of-flight rules for a
+// flight on course (degrees true).
+func hemisphericAltitude(alt int, course float64) (int, bool) {
+	if alt < hemisphericFloor {
+		return alt, true
+	}
+	east := course >= 0 && course < 180
+	valid := func(a int) bool {
+		if a%1000 != 0 {
+			return false
+		}
+		if a > 41000 {
+			if east {
+				return (a-41000)%4000 == 0
+			}
+			return (a-43000)%4000 == 0
+		}
+		return ((a/1000)%2 == 1) == east
+	}
+	if valid(alt) {
+		return alt, true
+	}
+	for a := alt / 1000 * 1000; a >= hemisphericFloor; a -= 1000 {
+		if valid(a) {
+			return a, false
+		}
+	}
+	for a := hemisphericFloor; ; a += 1000 {
+		if valid(a) {
+			return a, false
+		}
+	}
+}
+
+// AltitudeProblem records a departure whose filed altitude was nonsense or
+// was wrong for its direction of flight, for the report.
+type AltitudeProblem struct {
+	Callsign    string  `json:"callsign"`
+	Destination string  `json:"destination"`
+	Altitude    int     `json:"altitude"`
+	Course      float64 `json:"course,omitempty"`
+	Problem     string  `json:"problem"`
+	// Corrected is the altitude the departure was written with, if it was
+	// corrected; it's zero if the departure was dropped or left alone.
+	Corrected int `json:"corrected,omitempty"`
+}
+
+// checkAltitude applies the configured altitude check to a departure from
+// origin to dest (both [lon, lat], as FlightAware gives coordinates) filed
+// at alt feet.
+func (ft *fetcher) checkAltitude(callsign, destination string, alt int, origin, dest []float64) (int, *AltitudeProblem, bool) {
+	mode := ft.cfg.AltitudeCheck
+	if mode == altitudeCheckOff {
+		return alt, nil, true
+	}
+	p := AltitudeProblem{Callsign: callsign, Destination: destination, Altitude: alt}
+	if alt < minCruiseAltitude || alt > maxCruiseAltitude {
+		p.Problem = "implausible altitude"
+		return alt, &p, mode != altitudeCheckCorrect
+	}
+	if len(origin) != 2 || len(dest) != 2 {
+		return alt, nil, true
+	}
+	p.Course = math.Round(initialBearing(origin[1], origin[0], dest[1], dest[0]))
+	fixed, ok := hemisphericAltitude(alt, p.Course)
+	if ok {
+		return alt, nil, true
+	}
+	p.Problem = "wrong altitude for direction of flight"
+	if nearNorthSouth(p.Course) {
+		p.Problem = "possibly wrong altitude for direction of flight; the course is too close to north or south to be sure"
+		return alt, &p, true
+	}
+	if mode == altitudeCheckCorrect {
+		p.Corrected = fixed
+		alt = fixed
+	}
+	return alt, &p, true
+}
+
+func nearNorthSouth(course float64) bool {
+	d := math.Mod(course, 180)
+	return d < magneticMargin || d > 180-magneticMargin
+}
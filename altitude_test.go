@@ -0,0 +1,120 @@
+package main
+
+import "testing"
+
+func TestParseAltitude(t *testing.T) {
+	tests := []struct {
+		v    any
+		want int
+		ok   bool
+	}{
+		// Hundreds of feet, as FlightAware usually sends them.
+		{350.0, 35000, true},
+		{41.0, 4100, true},
+		{350.4, 35040, true},
+		// 1000 or more is already feet.
+		{1000.0, 1000, true},
+		{35000.0, 35000, true},
+		{60000.0, 60000, true},
+
+		{"FL350", 35000, true},
+		{"F350", 35000, true},
+		{"fl350", 35000, true},
+		{" 330 ", 33000, true},
+		{"35000", 35000, true},
+		{"35000FT", 35000, true},
+		{"35000'", 35000, true},
+		{"A045", 4500, true},
+		// Block altitudes use the bottom of the block.
+		{"FL330B350", 33000, true},
+		{"FL350B370", 35000, true},
+		{"330B370", 33000, true},
+		{"F350B390", 35000, true},
+
+		{nil, 0, false},
+		{"", 0, false},
+		{"FLXYZ", 0, false},
+		{"B350", 0, false},
+		{true, 0, false},
+
+		// Out of range.
+		{999.0, 0, false},
+		{0.0, 0, false},
+		{-350.0, 0, false},
+		{60001.0, 0, false},
+		{"FL999", 0, false},
+		{"FL000", 0, false},
+		{"-35000", 0, false},
+		{"FL999B999", 0, false},
+	}
+	for _, test := range tests {
+		got, err := parseAltitude(test.v)
+		if (err == nil) != test.ok || got != test.want {
+			t.Errorf("parseAltitude(%#v) = %v, %v; want %v, ok %v", test.v, got, err, test.want, test.ok)
+		}
+	}
+}
+
+func TestHemisphericAltitude(t *testing.T) {
+	tests := []struct {
+		alt    int
+		course float64
+		want   int
+		ok     bool
+	}{
+		// The rules don't apply down low.
+		{2000, 90, 2000, true},
+		{2500, 270, 2500, true},
+
+		// Odd thousands eastbound, even westbound.
+		{35000, 90, 35000, true},
+		{36000, 90, 35000, false},
+		{36000, 270, 36000, true},
+		{35000, 270, 34000, false},
+		{35500, 90, 35000, false},
+		{35000, 0, 35000, true},
+		{35000, 180, 34000, false},
+		{35000, 359, 34000, false},
+		{41000, 90, 41000, true},
+		{41000, 270, 40000, false},
+
+		// 4000 foot steps above FL410.
+		{45000, 90, 45000, true},
+		{43000, 90, 41000, false},
+		{43000, 270, 43000, true},
+		{47000, 270, 47000, true},
+		{45000, 270, 43000, false},
+
+		// With nothing valid below, the lowest valid altitude.
+		{3000, 270, 4000, false},
+	}
+	for _, test := range tests {
+		got, ok := hemisphericAltitude(test.alt, test.course)
+		if got != test.want || ok != test.ok {
+			t.Errorf("hemisphericAltitude(%v, %v) = %v, %v; want %v, %v", test.alt, test.course, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestCheckAltitudeNearNorthSouth(t *testing.T) {
+	ft := &fetcher{cfg: Config{AltitudeCheck: altitudeCheckCorrect}}
+	tests := []struct {
+		origin, dest []float64
+		alt, want    int
+		corrected    bool
+	}{
+		// KJFK to KMIA is well west of south, so it's corrected.
+		{[]float64{-73.78, 40.64}, []float64{-80.29, 25.79}, 35000, 34000, true},
+		// KJFK to CYUL is just east of north: only flagged.
+		{[]float64{-73.78, 40.64}, []float64{-73.74, 45.47}, 34000, 34000, false},
+		// Due south, by longitude.
+		{[]float64{-73.78, 40.64}, []float64{-73.78, 30.0}, 35000, 35000, false},
+	}
+	for _, test := range tests {
+		alt, p, ok := ft.checkAltitude("TEST1", "XXXX", test.alt, test.origin, test.dest)
+		if !ok || p == nil || alt != test.want || (p.Corrected != 0) != test.corrected {
+			t.Errorf("checkAltitude(%v, %v → %v) = %v, %+v, %v; want %v, corrected %v",
+				test.alt, test.origin, test.dest, alt, p, ok, test.want, test.corrected)
+		}
+	}
+}
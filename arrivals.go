@@ -0,0 +1,200 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ArrivalOrigin is an origin airport's share of an airport's arrivals:
+// how many flights came from it and which airlines flew them.
+type ArrivalOrigin struct {
+	Airport  string           `json:"airport"`
+	Count    int              `json:"count"`
+	Airlines []ArrivalAirline `json:"airlines"`
+}
+
+type ArrivalAirline struct {
+	Icao     string `json:"icao"`
+	Fleet    string `json:"fleet,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Callsign string `json:"callsign,omitempty"`
+	Count    int    `json:"count"`
+}
+
+// unknownGate is the gate of arrivals whose origin isn't in airports.json.
+const unknownGate = "unknown"
+
+// compassGates are the gates arrivals are grouped into by default, named
+// for the direction they arrive from.
+var compassGates = []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+
+// CornerPost is an arrival gate.
+type CornerPost struct {
+	Name string  `json:"name"`
+	STAR string  `json:"star,omitempty"`
+	From float64 `json:"from"`
+	To   float64 `json:"to"`
+}
+
+func (c CornerPost) contains(bearing float64) bool {
+	if c.From <= c.To {
+		return bearing >= c.From && bearing < c.To
+	}
+	return bearing >= c.From || bearing < c.To
+}
+
+// gate returns the corner post's name for grouping and file names.
+func (c CornerPost) gate() string {
+	if c.STAR != "" {
+		return c.STAR
+	}
+	return c.Name
+}
+
+// checkCornerPosts returns an error for the first corner post that's
+// malformed.
+func checkCornerPosts(posts []CornerPost) error {
+	var gates []string
+	for i, c := range posts {
+		if c.Name == "" {
+			return fmt.Errorf("corner post %v has no name", i)
+		}
+		if c.From < 0 || c.From >= 360 || c.To < 0 || c.To >= 360 || c.From == c.To {
+			return fmt.Errorf("corner post %v: %v to %v isn't a range of bearings", c.Name, c.From, c.To)
+		}
+		if slices.Contains(gates, c.gate()) {
+			return fmt.Errorf("corner post %v: %v is used by more than one corner post", c.Name, c.gate())
+		}
+		gates = append(gates, c.gate())
+	}
+	return nil
+}
+
+// arrivalGate returns the gate an arrival from origin to airport would use,
+// based on the bearing of origin from airport.
+func (ft *fetcher) arrivalGate(origin, airport string) string {
+	bearing, ok := originBearing(ft.airports, airport, origin)
+	if !ok {
+		return unknownGate
+	}
+	if posts := ft.cfg.CornerPosts; len(posts) > 0 {
+		if i := slices.IndexFunc(posts, func(c CornerPost) bool { return c.contains(bearing) }); i != -1 {
+			return posts[i].gate()
+		}
+		return unknownGate
+	}
+	return compassGates[int(math.Round(bearing/45))%len(compassGates)]
+}
+
+// aggregateArrivals combines arrivals into one entry per origin airport
+// with the airlines that flew from it, busiest first.
+func aggregateArrivals(arrivals []Arrivals) []ArrivalOrigin {
+	origins := make(map[string]*ArrivalOrigin)
+	for _, a := range arrivals {
+		o, ok := origins[a.Airport]
+		if !ok {
+			o = &ArrivalOrigin{Airport: a.Airport}
+			origins[a.Airport] = o
+		}
+		o.Count++
+		i := slices.IndexFunc(o.Airlines, func(al ArrivalAirline) bool {
+			return al.Icao == a.Icao && al.Fleet == a.Fleet
+		})
+		if i == -1 {
+			o.Airlines = append(o.Airlines, ArrivalAirline{Icao: a.Icao, Fleet: a.Fleet, Name: a.Name, Callsign: a.Callsign})
+			i = len(o.Airlines) - 1
+		}
+		o.Airlines[i].Count++
+	}
+
+	var result []ArrivalOrigin
+	for _, o := range origins {
+		slices.SortFunc(o.Airlines, func(a, b ArrivalAirline) int {
+			if a.Count != b.Count {
+				return cmp.Compare(b.Count, a.Count)
+			}
+			return strings.Compare(a.Icao+a.Fleet, b.Icao+b.Fleet)
+		})
+		result = append(result, *o)
+	}
+	slices.SortFunc(result, func(a, b ArrivalOrigin) int {
+		if a.Count != b.Count {
+			return cmp.Compare(b.Count, a.Count)
+		}
+		return strings.Compare(a.Airport, b.Airport)
+	})
+	return result
+}
+
+// arrivalsByGate splits airport's arrivals up by the gate they use.
+func (ft *fetcher) arrivalsByGate(airport string, arrivals []Arrivals) map[string][]Arrivals {
+	byGate := make(map[string][]Arrivals)
+	for _, a := range arrivals {
+		gate := ft.arrivalGate(a.Airport, airport)
+		byGate[gate] = append(byGate[gate], a)
+	}
+	return byGate
+}
+
+// groupArrivals aggregates arrivals at airport separately for each gate.
+func (ft *fetcher) groupArrivals(airport string, arrivals []Arrivals) map[string][]ArrivalOrigin {
+	groups := make(map[string][]ArrivalOrigin)
+	for gate, as := range ft.arrivalsByGate(airport, arrivals) {
+		groups[gate] = aggregateArrivals(as)
+	}
+	return groups
+}
+
+// arrivalsOutput returns what's written for airport's arrivals.
+func (ft *fetcher) arrivalsOutput(airport string, arrivals []Arrivals) any {
+	switch {
+	case ft.cfg.GroupArrivals:
+		return ft.groupArrivals(airport, arrivals)
+	case ft.cfg.AggregateArrivals:
+		return aggregateArrivals(arrivals)
+	default:
+		return arrivals
+	}
+}
+
+// writeArrivals writes airport's arrivals to the arrivals output, or if the
+// configuration asks for them to be split up, to a file for each gate named
+// after it, e.g. arrivals-CAMRN4.json.
+func (ft *fetcher) writeArrivals(airport string, arrivals []Arrivals) error {
+	path := ft.cfg.outputPath(ft.cfg.Output.Arrivals, airport)
+	if !ft.cfg.SplitArrivals {
+		return writeOutput(path, ft.arrivalsOutput(airport, arrivals))
+	}
+	byGate := ft.arrivalsByGate(airport, arrivals)
+	// Every configured gate gets a file, even if it's empty, so that a
+	// file from a previous run doesn't linger.
+	for _, c := range ft.cfg.CornerPosts {
+		if _, ok := byGate[c.gate()]; !ok {
+			byGate[c.gate()] = []Arrivals{}
+		}
+	}
+	for _, gate := range sortedKeys(byGate) {
+		var out any = byGate[gate]
+		if ft.cfg.AggregateArrivals {
+			out = aggregateArrivals(byGate[gate])
+		}
+		if err := writeOutput(gatePath(path, gate), out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gatePath returns the path of gate's arrivals, given the path of all of
+// them.
+func gatePath(path, gate string) string {
+	if strings.Contains(path, "{gate}") {
+		return strings.ReplaceAll(path, "{gate}", gate)
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + gate + ext
+}
@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestArrivalGate(t *testing.T) {
+	airports := map[string]Airport{
+		"KJFK": {Lat: 40.6398, Lon: -73.7789},
+		"KMIA": {Lat: 25.7932, Lon: -80.2906},
+		"KBOS": {Lat: 42.3643, Lon: -71.0052},
+		"KLAX": {Lat: 33.9425, Lon: -118.4081},
+		"KATL": {Lat: 33.6367, Lon: -84.4281},
+		"CYUL": {Lat: 45.4706, Lon: -73.7408},
+		"EGLL": {Lat: 51.4706, Lon: -0.4619},
+	}
+	posts := []CornerPost{
+		{Name: "CAMRN", STAR: "CAMRN4", From: 120, To: 240},
+		{Name: "LENDY", STAR: "LENDY8", From: 240, To: 30},
+		{Name: "PARCH", From: 30, To: 100},
+	}
+	tests := []struct {
+		origin string
+		posts  []CornerPost
+		want   string
+	}{
+		// Compass gates are named for the direction the origin is in.
+		{"KMIA", nil, "S"},
+		{"KBOS", nil, "NE"},
+		{"EGLL", nil, "NE"},
+		{"KLAX", nil, "W"},
+		{"KATL", nil, "SW"},
+		{"CYUL", nil, "N"},
+		{"XXXX", nil, unknownGate},
+
+		// Corner posts use the same bearings, and may wrap past north.
+		{"KMIA", posts, "CAMRN4"},
+		{"KATL", posts, "CAMRN4"},
+		{"KLAX", posts, "LENDY8"},
+		{"CYUL", posts, "LENDY8"},
+		{"KBOS", posts, "PARCH"},
+		{"EGLL", posts, "PARCH"},
+		{"XXXX", posts, unknownGate},
+		// Bearings no corner post covers.
+		{"KMIA", posts[1:], unknownGate},
+	}
+	for _, test := range tests {
+		ft := &fetcher{cfg: Config{CornerPosts: test.posts}, airports: airports}
+		if got := ft.arrivalGate(test.origin, "KJFK"); got != test.want {
+			t.Errorf("arrivalGate(%v) with %v corner posts = %v; want %v", test.origin, len(test.posts), got, test.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Callsign is an ICAO airline callsign split into its parts, e.g. BAW12AB
+// is airline BAW, flight number 12 and suffix AB.
+type Callsign struct {
+	Airline string
+	Number  string
+	Suffix  string
+}
+
+func (c Callsign) String() string {
+	return c.Airline + c.Number + c.Suffix
+}
+
+// parseCallsign parses an airline callsign as reported by OpenSky, which
+// pads callsigns with trailing spaces.
+func parseCallsign(raw string) (Callsign, bool) {
+	s := strings.ToUpper(strings.TrimSpace(raw))
+	if len(s) < 4 || len(s) > 7 {
+		return Callsign{}, false
+	}
+	for _, ch := range s[:3] {
+		if ch < 'A' || ch > 'Z' {
+			return Callsign{}, false
+		}
+	}
+	id := s[3:]
+	if !unicode.IsDigit(rune(id[0])) {
+		return Callsign{}, false
+	}
+	for _, ch := range id {
+		if !(ch >= 'A' && ch <= 'Z') && !unicode.IsDigit(ch) {
+			return Callsign{}, false
+		}
+	}
+
+	n := strings.IndexFunc(id, func(ch rune) bool { return !unicode.IsDigit(ch) })
+	if n == -1 {
+		n = len(id)
+	}
+	return Callsign{Airline: s[:3], Number: id[:n], Suffix: id[n:]}, true
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// CallsignFilters decides which flights are used, based on their callsign.
+type CallsignFilters struct {
+	AllowOnly bool               `json:"allow_only,omitempty"`
+	Include   CallsignFilterList `json:"include"`
+	Exclude   CallsignFilterList `json:"exclude"`
+	// Categories adds airlines to the built-in categories or defines new
+	// ones, keyed by category name.
+	Categories map[string][]string `json:"categories,omitempty"`
+
+	include, exclude []*regexp.Regexp
+}
+
+// CallsignFilterList matches a flight if its airline is in Airlines, its
+// airline belongs to one of Categories, or its full callsign matches one of
+// the regular expressions in Patterns.
+type CallsignFilterList struct {
+	Airlines   []string `json:"airlines,omitempty"`
+	Patterns   []string `json:"patterns,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+}
+
+// callsignCategories are the airlines in each of the built-in categories.
+// They can be extended from callsign-filters.json.
+var callsignCategories = map[string][]string{
+	"military": {"RCH", "CNV", "PAT", "SAM", "RRR", "CFC", "GAF", "FAF", "IAM", "ASY", "KIW", "BAF", "NAF"},
+	"cargo": {"FDX", "UPS", "GTI", "ABX", "ATN", "CKS", "CLX", "DHK", "DHL", "BCS", "BOX", "GEC", "CAO", "CKK",
+		"NCA", "KYE", "PAC", "MTN", "SOO", "WGN", "AJT", "LCO"},
+	"charter": {"EJA", "LXJ", "JTL", "XOJ", "VJT", "OAE", "SWQ"},
+}
+
+func defaultCallsignFilters() CallsignFilters {
+	return CallsignFilters{
+		Exclude: CallsignFilterList{
+			Airlines: []string{"CFR"},
+		},
+	}
+}
+
+// loadCallsignFilters reads the callsign filters at path in the resources
+// folder. If there's no file, the defaults are used.
+func loadCallsignFilters(path string) (CallsignFilters, error) {
+	filters, err := loadJSONResource[CallsignFilters](path, false)
+	if errors.Is(err, fs.ErrNotExist) {
+		filters := defaultCallsignFilters()
+		return filters, filters.compile()
+	} else if err != nil {
+		return filters, err
+	}
+	if err := filters.compile(); err != nil {
+		return filters, fmt.Errorf("%v: %w", resourceName(path), err)
+	}
+	return filters, nil
+}
+
+// compile compiles the regular expressions and checks that every category
+// that's used is known. It must be called before Allowed.
+func (c *CallsignFilters) compile() error {
+	var err error
+	if c.include, err = compilePatterns(c.Include.Patterns); err != nil {
+		return err
+	}
+	if c.exclude, err = compilePatterns(c.Exclude.Patterns); err != nil {
+		return err
+	}
+	for _, cat := range append(slices.Clone(c.Include.Categories), c.Exclude.Categories...) {
+		if _, ok := callsignCategories[cat]; !ok {
+			if _, ok := c.Categories[cat]; !ok {
+				return fmt.Errorf("unknown category %q", cat)
+			}
+		}
+	}
+	return nil
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var re []*regexp.Regexp
+	for _, p := range patterns {
+		r, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", p, err)
+		}
+		re = append(re, r)
+	}
+	return re, nil
+}
+
+// Allowed reports whether a flight with the given airline ICAO and full
+// callsign should be used.
+func (c CallsignFilters) Allowed(airline, callsign string) bool {
+	if c.matches(c.Include, c.include, airline, callsign) {
+		return true
+	}
+	if c.AllowOnly {
+		return false
+	}
+	return !c.matches(c.Exclude, c.exclude, airline, callsign)
+}
+
+func (c CallsignFilters) matches(l CallsignFilterList, patterns []*regexp.Regexp, airline, callsign string) bool {
+	airline = strings.ToUpper(airline)
+	if slices.Contains(l.Airlines, airline) {
+		return true
+	}
+	for _, cat := range l.Categories {
+		if slices.Contains(callsignCategories[cat], airline) || slices.Contains(c.Categories[cat], airline) {
+			return true
+		}
+	}
+	for _, re := range patterns {
+		if re.MatchString(callsign) {
+			return true
+		}
+	}
+	return false
+}
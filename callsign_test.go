@@ -0,0 +1,49 @@
+package main
+
+import "testing"
+
+func TestParseCallsign(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want Callsign
+		ok   bool
+	}{
+		// Callsigns as OpenSky reports them, padded to eight characters.
+		{"UAL123  ", Callsign{"UAL", "123", ""}, true},
+		{"BAW12AB ", Callsign{"BAW", "12", "AB"}, true},
+		{"SWA1A   ", Callsign{"SWA", "1", "A"}, true},
+		{"DLH4YE  ", Callsign{"DLH", "4", "YE"}, true},
+		{"EZY91TJ ", Callsign{"EZY", "91", "TJ"}, true},
+		{"AAL2345 ", Callsign{"AAL", "2345", ""}, true},
+		{"RYR1A2B ", Callsign{"RYR", "1", "A2B"}, true},
+		{"JBU1    ", Callsign{"JBU", "1", ""}, true},
+		{"  DAL12 ", Callsign{"DAL", "12", ""}, true},
+		{"ual123", Callsign{"UAL", "123", ""}, true},
+
+		// Too short or too long.
+		{"", Callsign{}, false},
+		{"        ", Callsign{}, false},
+		{"UAL     ", Callsign{}, false},
+		{"UAL12345", Callsign{}, false},
+		{"BAW123ABC", Callsign{}, false},
+
+		// Registrations and other non-airline callsigns.
+		{"N123AB  ", Callsign{}, false},
+		{"N12     ", Callsign{}, false},
+		{"GABCD   ", Callsign{}, false},
+		{"DEIAB   ", Callsign{}, false},
+		{"CFABC   ", Callsign{}, false},
+
+		// Malformed designators and flight identifiers.
+		{"U2L123  ", Callsign{}, false},
+		{"UALA12  ", Callsign{}, false},
+		{"UAL-12  ", Callsign{}, false},
+		{"UAL12-A ", Callsign{}, false},
+	}
+	for _, test := range tests {
+		got, ok := parseCallsign(test.raw)
+		if ok != test.ok || got != test.want {
+			t.Errorf("parseCallsign(%q) = %+v, %v; want %+v, %v", test.raw, got, ok, test.want, test.ok)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Config holds all of the settings for a run.
+type Config struct {
+	// Airport is a single airport to fetch; Airports can be used instead
+	// to fetch several in one run.
+	Airport  string   `json:"airport,omitempty"`
+	Airports []string `json:"airports,omitempty"`
+	// AllowUnknownAirports fetches ICAO codes that aren't in airports.json
+	// instead of treating them as mistakes.
+	AllowUnknownAirports bool `json:"allow_unknown_airports,omitempty"`
+	Amount               int  `json:"amount"`
+	// Departures and Arrivals override Amount for one side. Only, if set
+	// to "departures" or "arrivals", fetches just that side.
+	Departures int    `json:"departures,omitempty"`
+	Arrivals   int    `json:"arrivals,omitempty"`
+	Only       string `json:"only,omitempty"`
+	// MaxAttempts limits how many FlightAware lookups are made for each
+	// airport's departures while trying to get Amount usable ones. Zero
+	// means no limit.
+	MaxAttempts int    `json:"max_attempts,omitempty"`
+	Window      Window `json:"window"`
+
+	// Resources is the folder that resource files are read from. If it's
+	// empty, the resources folder next to the executable or in the current
+	// directory is used.
+	Resources string `json:"resources,omitempty"`
+	// ViceResources is a vice installation to read the airline database
+	// from, so that fleets always match the vice version in use.
+	ViceResources string `json:"vice_resources,omitempty"`
+	// ExitExceptions and ScratchpadRules may be given inline; if they're
+	// omitted, exit-exeptions.json and scratchpad-rules.json are read
+	// from the resources folder instead.
+	ExitExceptions  *exitExeptions   `json:"exit_exceptions,omitempty"`
+	ScratchpadRules *ScratchpadRules `json:"scratchpad_rules,omitempty"`
+
+	// GA keeps general aviation flights that use their registration as a
+	// callsign instead of dropping them. Their types are looked up in
+	// registry.json in the resources folder if the flight plan doesn't have
+	// one.
+	GA bool `json:"ga,omitempty"`
+
+	// FleetPriority lists fleet names in order of preference for when an
+	// aircraft type is in more than one of an airline's fleets.
+	FleetPriority []string `json:"fleet_priority,omitempty"`
+
+	// AirlineNames adds each airline's name and radio callsign from
+	// openscope-airlines.json to the output.
+	AirlineNames bool `json:"airline_names,omitempty"`
+
+	// AltitudeCheck checks cruise altitudes against the direction of
+	// flight. "flag" lists problems in the report and "correct" also fixes
+	// them, dropping departures with altitudes that can't be fixed.
+	AltitudeCheck string `json:"altitude_check,omitempty"`
+
+	// Sampling is the strategy for choosing which callsigns to look up
+	// (see sampling.go); by default they're taken in OpenSky's order.
+	// Seed seeds the random choices so that a run can be repeated; if
+	// it's zero, a seed is picked and recorded in the report.
+	Sampling string `json:"sampling,omitempty"`
+	Seed     int64  `json:"seed,omitempty"`
+
+	// AggregateArrivals writes arrivals as one entry per origin airport
+	// with the airlines that flew from it and how often, instead of one
+	// per flight. GroupArrivals does the same separately for each gate
+	// the arrivals come in through, based on the bearing of the origin.
+	AggregateArrivals bool `json:"aggregate_arrivals,omitempty"`
+	GroupArrivals     bool `json:"group_arrivals,omitempty"`
+	// CornerPosts are the gates arrivals are grouped by; without them, the
+	// points of the compass are used. SplitArrivals writes each gate's
+	// arrivals to its own file.
+	CornerPosts   []CornerPost `json:"corner_posts,omitempty"`
+	SplitArrivals bool         `json:"split_arrivals,omitempty"`
+
+	// Details writes an extended record of each departure, with its
+	// planned speed, time en route and aircraft details, to
+	// Output.Details.
+	Details bool `json:"details,omitempty"`
+
+	// Distance limits departures to destinations within a range of
+	// distances from the airport.
+	Distance DistanceFilter `json:"distance"`
+
+	// Routes limits departures to certain exits and destinations.
+	Routes RouteFilters `json:"routes"`
+
+	// Wake limits departures to certain wake categories and engine
+	// classes.
+	Wake WakeFilter `json:"wake"`
+
+	// CrossCheck says how departures' flight plans are checked against
+	// what OpenSky saw.
+	CrossCheck CrossCheck `json:"cross_check"`
+
+	// Filters may also be given inline instead of callsign-filters.json.
+	Filters *CallsignFilters `json:"filters,omitempty"`
+	Output  Output           `json:"output"`
+}
+
+// Window is the time range that OpenSky is asked for flights in.
+type Window struct {
+	Begin string `json:"begin,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+// Output holds the paths that departures and arrivals are written to.
+type Output struct {
+	Departures string `json:"departures"`
+	Arrivals   string `json:"arrivals"`
+	// Details is only written if Config.Details is set.
+	Details string `json:"details"`
+	// Report covers every airport in the run, so it's never split up.
+	Report string `json:"report"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		Amount: 50,
+		Output: Output{
+			Departures: "departures.json",
+			Arrivals:   "arrivals.json",
+			Details:    "departure-details.json",
+			Report:     "report.json",
+		},
+	}
+}
+
+// loadConfig reads the facility configuration at path. Anything the file
+// doesn't set keeps its default value.
+func loadConfig(path string) (Config, error) {
+	c := defaultConfig()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, fmt.Errorf("%v: %w", path, err)
+	}
+	return c, nil
+}
+
+// departureAmount and arrivalAmount return how many departures and arrivals
+// to produce for each airport; zero means that side isn't fetched.
+func (c Config) departureAmount() int {
+	if c.Only == "arrivals" {
+		return 0
+	} else if c.Departures > 0 {
+		return c.Departures
+	}
+	return c.Amount
+}
+
+func (c Config) arrivalAmount() int {
+	if c.Only == "departures" {
+		return 0
+	} else if c.Arrivals > 0 {
+		return c.Arrivals
+	}
+	return c.Amount
+}
+
+// checkOnly returns an error if Only isn't a side that can be fetched.
+func (c Config) checkOnly() error {
+	switch c.Only {
+	case "", "departures", "arrivals":
+		return nil
+	default:
+		return fmt.Errorf("%q is not a valid -only; use \"departures\" or \"arrivals\"", c.Only)
+	}
+}
+
+// airports returns the airports to fetch, with duplicates and blanks
+// removed.
+func (c Config) airports() []string {
+	var airports []string
+	for _, ap := range append([]string{c.Airport}, c.Airports...) {
+		ap = strings.ToUpper(strings.TrimSpace(ap))
+		if ap != "" && !slices.Contains(airports, ap) {
+			airports = append(airports, ap)
+		}
+	}
+	return airports
+}
+
+// outputPath returns where the output at path should be written for
+// airport.
+func (c Config) outputPath(path, airport string) string {
+	if strings.Contains(path, "{airport}") {
+		return strings.ReplaceAll(path, "{airport}", airport)
+	}
+	if len(c.airports()) > 1 {
+		return filepath.Join(filepath.Dir(path), airport, filepath.Base(path))
+	}
+	return path
+}
+
+// window returns the begin and end times of the OpenSky query.
+func (c Config) window() (time.Time, time.Time, error) {
+	nowUTC := time.Now().UTC()
+	end := nowUTC
+	if c.Window.End != "" {
+		t, err := time.Parse(time.RFC3339, c.Window.End)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("window end: %w", err)
+		}
+		end = t.UTC()
+	}
+
+	// Constrain range to at most previous day + today (UTC) to avoid crossing 3 partitions
+	startOfTodayUTC := time.Date(nowUTC.Year(), nowUTC.Month(), nowUTC.Day(), 0, 0, 0, 0, time.UTC)
+	begin := startOfTodayUTC.Add(-24 * time.Hour)
+	if c.Window.Begin != "" {
+		t, err := time.Parse(time.RFC3339, c.Window.Begin)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("window begin: %w", err)
+		}
+		begin = t.UTC()
+	}
+
+	if !begin.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("window begin %v is not before end %v", begin, end)
+	}
+	return begin, end, nil
+}
+
+// pinWindow replaces the window with the times it resolves to.
+func (c *Config) pinWindow() error {
+	begin, end, err := c.window()
+	if err != nil {
+		return err
+	}
+	c.Window = Window{Begin: begin.Format(time.RFC3339), End: end.Format(time.RFC3339)}
+	return nil
+}
+
+// loadExitExceptions returns the inline exit exceptions if there are any
+// and otherwise reads them from the resources folder.
+func (c Config) loadExitExceptions() (exitExeptions, error) {
+	if c.ExitExceptions != nil {
+		return *c.ExitExceptions, nil
+	}
+	return loadExitExceptions("exit-exeptions.json")
+}
+
+// loadScratchpadRules returns the inline scratchpad rules if there are any
+// and otherwise reads them from the resources folder.
+func (c Config) loadScratchpadRules() (ScratchpadRules, error) {
+	if c.ScratchpadRules != nil {
+		return *c.ScratchpadRules, nil
+	}
+	return loadScratchpadRules("scratchpad-rules.json")
+}
+
+// loadCallsignFilters returns the inline callsign filters if there are any
+// and otherwise reads them from the resources folder.
+func (c Config) loadCallsignFilters() (CallsignFilters, error) {
+	if c.Filters != nil {
+		filters := *c.Filters
+		return filters, filters.compile()
+	}
+	return loadCallsignFilters("callsign-filters.json")
+}
+
+// writeOutput writes v as indented JSON to path, creating any folders
+// that path needs.
+func writeOutput(path string, v any) error {
+	f, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, f, 0o644)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// defaultMismatchTolerance is how far apart OpenSky's first sighting of a
+// departure and FlightAware's takeoff time may be before they're taken to be
+// different flights.
+const defaultMismatchTolerance = 90 * time.Minute
+
+// CrossCheck configures checking each departure's flight plan against what
+// OpenSky saw, which catches a reused flight number yielding the plan of an
+// unrelated leg.
+type CrossCheck struct {
+	Tolerance string `json:"tolerance,omitempty"`
+	Discard   bool   `json:"discard,omitempty"`
+}
+
+// tolerance returns the allowed difference between OpenSky's and
+// FlightAware's departure times.
+func (c CrossCheck) tolerance() (time.Duration, error) {
+	if c.Tolerance == "" {
+		return defaultMismatchTolerance, nil
+	}
+	d, err := time.ParseDuration(c.Tolerance)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%q isn't a tolerance; use e.g. 90m or 2h", c.Tolerance)
+	}
+	return d, nil
+}
+
+// Mismatch is a departure whose flight plan disagrees with OpenSky, for
+// the report. What is "destination" or "departure_time".
+type Mismatch struct {
+	Callsign   string `json:"callsign"`
+	What       string `json:"what"`
+	OpenSky    string `json:"opensky"`
+	FlightPlan string `json:"flight_plan"`
+	Discarded  bool   `json:"discarded,omitempty"`
+}
+
+// crossCheck compares the leg of aircraft's flight plan that's about to be
+// used with what OpenSky saw of it, and reports any mismatches.
+func (ft *fetcher) crossCheck(airport string, aircraft CallsignOutput, flight FlightAwareFlight) bool {
+	var mismatches []Mismatch
+	if aircraft.Destination != "" && flight.Destination.Icao != "" && aircraft.Destination != flight.Destination.Icao {
+		mismatches = append(mismatches, Mismatch{
+			What:       "destination",
+			OpenSky:    aircraft.Destination,
+			FlightPlan: flight.Destination.Icao,
+		})
+	}
+	// The tolerance was checked at startup.
+	tolerance, _ := ft.cfg.CrossCheck.tolerance()
+	if seen, took := aircraft.FirstSeen, flight.departureTime(); seen != 0 && took != 0 &&
+		time.Duration(max(seen-took, took-seen))*time.Second > tolerance {
+		mismatches = append(mismatches, Mismatch{
+			What:       "departure_time",
+			OpenSky:    time.Unix(seen, 0).UTC().Format(time.RFC3339),
+			FlightPlan: time.Unix(took, 0).UTC().Format(time.RFC3339),
+		})
+	}
+	for _, m := range mismatches {
+		m.Callsign = aircraft.ICAOCallsign
+		m.Discarded = ft.cfg.CrossCheck.Discard
+		log.Printf("%v: OpenSky has %v %v but the flight plan has %v", m.Callsign, m.What, m.OpenSky, m.FlightPlan)
+		ft.report.mismatch(airport, m)
+	}
+	return len(mismatches) == 0 || !ft.cfg.CrossCheck.Discard
+}
@@ -0,0 +1,51 @@
+package main
+
+import "strings"
+
+// DepartureDetails is the extended record of a departure, with what the
+// flight plan and FlightAware's aircraft data say that vice doesn't use, so
+// that plans can be sanity-checked.
+type DepartureDetails struct {
+	Callsign    string `json:"callsign"`
+	Destination string `json:"destination"`
+	Exit        string `json:"exit"`
+	Type        string `json:"type"`
+	// EquipmentSuffix is the filed equipment suffix (e.g. "L"), if
+	// FlightAware has one.
+	EquipmentSuffix string `json:"equipment_suffix,omitempty"`
+	Manufacturer    string `json:"manufacturer,omitempty"`
+	Model           string `json:"model,omitempty"`
+	EngineType      string `json:"engine_type,omitempty"`
+	EngineCount     int    `json:"engine_count,omitempty"`
+	Altitude        int    `json:"altitude"`
+	// Speed is the filed true airspeed in knots, ETE the estimated time
+	// en route in minutes and DirectDistance FlightAware's distance to the
+	// destination in statute miles.
+	Speed          int `json:"speed,omitempty"`
+	ETE            int `json:"ete,omitempty"`
+	DirectDistance int `json:"direct_distance,omitempty"`
+	// Distance is the great-circle distance to Destination in nautical
+	// miles and Haul classifies it as short, medium or long.
+	Distance int    `json:"distance,omitempty"`
+	Haul     string `json:"haul,omitempty"`
+	// Wake is the legacy wake turbulence category (J, H, B, L or S),
+	// RECAT the RECAT one (A to F) and Engine "jet", "turboprop" or
+	// "piston", where they're known.
+	Wake   string `json:"wake,omitempty"`
+	RECAT  string `json:"recat,omitempty"`
+	Engine string `json:"engine,omitempty"`
+}
+
+// equipmentSuffix returns the equipment suffix of an ICAO-style aircraft
+// type such as "H/B77W/L" or "B738/L", or "" if there isn't one.
+func equipmentSuffix(aircraftType string) string {
+	parts := strings.Split(strings.TrimSpace(aircraftType), "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	// A lone leading letter is the wake category, not the type.
+	if len(parts) == 2 && len(parts[0]) == 1 {
+		return ""
+	}
+	return parts[len(parts)-1]
+}
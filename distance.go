@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	earthRadiusNM = 3440.065
+	milesPerNM    = 1.150779
+	kmPerNM       = 1.852
+)
+
+// greatCircleNM returns the great-circle distance in nautical miles
+// between two points.
+func greatCircleNM(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := math.Pi / 180
+	dphi := (lat2 - lat1) * rad
+	dl := (lon2 - lon1) * rad
+	a := math.Sin(dphi/2)*math.Sin(dphi/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dl/2)*math.Sin(dl/2)
+	return 2 * earthRadiusNM * math.Asin(math.Sqrt(a))
+}
+
+// distanceNM returns the distance between two [lon, lat] locations, as
+// coord returns them, or ok false if either isn't known.
+func distanceNM(from, to []float64) (float64, bool) {
+	if len(from) != 2 || len(to) != 2 {
+		return 0, false
+	}
+	return greatCircleNM(from[1], from[0], to[1], to[0]), true
+}
+
+// haul classifies a flight of nm nautical miles as "short", "medium" or
+// "long" haul, with the same limits used for picking fleets.
+func haul(nm float64) string {
+	switch miles := nm * milesPerNM; {
+	case miles < shortHaulMiles:
+		return "short"
+	case miles >= longHaulMiles:
+		return "long"
+	default:
+		return "medium"
+	}
+}
+
+// parseDistance parses a distance such as "300nm", "500mi" or "800km" and
+// returns it in nautical miles. A number on its own is nautical miles.
+func parseDistance(dist string) (float64, error) {
+	s := strings.ToLower(strings.TrimSpace(dist))
+	scale := 1.0
+	switch {
+	case strings.HasSuffix(s, "nm"):
+		s = strings.TrimSuffix(s, "nm")
+	case strings.HasSuffix(s, "mi"):
+		s, scale = strings.TrimSuffix(s, "mi"), 1/milesPerNM
+	case strings.HasSuffix(s, "km"):
+		s, scale = strings.TrimSuffix(s, "km"), 1/kmPerNM
+	}
+	d, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%q isn't a distance; use e.g. 300nm, 500mi or 800km", dist)
+	}
+	return d * scale, nil
+}
+
+// DistanceFilter keeps departures whose destinations are between Min and
+// Max (e.g. "300nm") from the airport. Either may be empty.
+type DistanceFilter struct {
+	Min string `json:"min,omitempty"`
+	Max string `json:"max,omitempty"`
+}
+
+// limits returns the filter's limits in nautical miles; max is zero if
+// there isn't one.
+func (f DistanceFilter) limits() (min, max float64, err error) {
+	if f.Min != "" {
+		if min, err = parseDistance(f.Min); err != nil {
+			return
+		}
+	}
+	if f.Max != "" {
+		if max, err = parseDistance(f.Max); err != nil {
+			return
+		}
+		if max < min {
+			err = fmt.Errorf("maximum distance %v is less than the minimum %v", f.Max, f.Min)
+		}
+	}
+	return
+}
+
+func (f DistanceFilter) active() bool {
+	return f.Min != "" || f.Max != ""
+}
+
+// allowed reports whether a departure of nm nautical miles should be
+// kept. The limits have already been checked by limits.
+func (f DistanceFilter) allowed(nm float64) bool {
+	min, max, _ := f.limits()
+	return nm >= min && (max == 0 || nm <= max)
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vbauerster/mpb"
+	"golang.org/x/net/html"
+)
+
+// fetcher holds everything that's shared between the airports in a run.
+type fetcher struct {
+	cfg       Config
+	token     string
+	filters   CallsignFilters
+	report    *Report
+	progress  *mpb.Progress
+	openscope map[string]Airlines
+	// telephony maps airline ICAO codes to their radio callsigns.
+	telephony map[string]string
+	// gaPrefixes and registry are only set when cfg.GA is.
+	gaPrefixes []string
+	registry   Registry
+	// types are the aircraft type families tried when an airline has no
+	// fleet with a flight's type.
+	types TypeEquivalents
+	// scRules and exceptions are the scratchpad rules and exit exceptions
+	// departures are written with.
+	scRules    ScratchpadRules
+	exceptions exitExeptions
+	// aircraftTypes are the wake categories and engine classes of known
+	// aircraft types.
+	aircraftTypes map[string]AircraftTypeInfo
+	// airports are the known airports' locations, by ICAO code.
+	airports map[string]Airport
+	limiter  *rateLimiter
+	// rec is set when responses are being recorded or replayed.
+	rec *recording
+
+	mu    sync.Mutex
+	cache map[string]FlightAwareResponse
+}
+
+func newFetcher(cfg Config, token string, filters CallsignFilters, rec *recording) *fetcher {
+	return &fetcher{
+		cfg:      cfg,
+		token:    token,
+		filters:  filters,
+		rec:      rec,
+		report:   newReport(),
+		progress: mpb.New(mpb.WithWaitGroup(&wg)),
+		limiter:  &rateLimiter{interval: 15 * time.Second},
+		cache:    make(map[string]FlightAwareResponse),
+	}
+}
+
+// rateLimiter spaces out requests so that there is at least interval
+// between any two of them, no matter which airport they're for.
+type rateLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func (r *rateLimiter) wait() {
+	r.mu.Lock()
+	now := time.Now()
+	at := r.next
+	if at.Before(now) {
+		at = now
+	}
+	r.next = at.Add(r.interval)
+	r.mu.Unlock()
+
+	time.Sleep(time.Until(at))
+}
+
+// getOpenSky fetches the departures or arrivals ("departure" or "arrival")
+// for airport in the time window [begin, end), ordered by when they were
+// first seen so that the order doesn't depend on OpenSky's.
+func (ft *fetcher) getOpenSky(kind, airport string, begin, end int64) (Sky, error) {
+	name := fmt.Sprintf("%v-%v-%v-%v", airport, kind, begin, end)
+	var r Sky
+	var err error
+	if ft.rec != nil && ft.rec.replay {
+		err = ft.rec.load("opensky", name, &r)
+	} else {
+		r, err = ft.fetchOpenSky(kind, airport, begin, end)
+		if err == nil && ft.rec != nil {
+			err = ft.rec.save("opensky", name, r)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(r, func(a, b SkyFlight) int {
+		if a.FirstSeen != b.FirstSeen {
+			return cmp.Compare(a.FirstSeen, b.FirstSeen)
+		}
+		return strings.Compare(a.Callsign, b.Callsign)
+	})
+	return r, nil
+}
+
+// maxWindowExtensions is how many days before the configured window a
+// flightSource will go back for more flights.
+const maxWindowExtensions = 7
+
+// flightSource hands out an airport's departures or arrivals from OpenSky a
+// window at a time.
+type flightSource struct {
+	ft            *fetcher
+	kind, airport string
+	begin, end    int64
+	extensions    int
+}
+
+func (ft *fetcher) newFlightSource(kind, airport string, begin, end int64) *flightSource {
+	return &flightSource{ft: ft, kind: kind, airport: airport, begin: begin, end: end, extensions: -1}
+}
+
+// next returns the flights in the next window. ok is false when there are
+// no more windows to try.
+func (s *flightSource) next() (Sky, bool) {
+	if s.extensions == maxWindowExtensions {
+		return nil, false
+	}
+	if s.extensions++; s.extensions > 0 {
+		s.begin, s.end = s.begin-24*60*60, s.begin
+		log.Printf("%v: extending the %v window back to %v", s.airport, s.kind, time.Unix(s.begin, 0).UTC())
+	}
+	r, err := s.ft.getOpenSky(s.kind, s.airport, s.begin, s.end)
+	if err != nil {
+		log.Printf("%v: error fetching %vs: %v", s.airport, s.kind, err)
+		return nil, false
+	}
+	if _, ok := s.ft.airports[s.airport]; !ok && len(r) == 0 {
+		// Most likely the code is wrong, so earlier days won't have any
+		// flights either.
+		log.Printf("%v: no %vs for an airport that isn't in airports.json; not extending the window", s.airport, s.kind)
+		s.extensions = maxWindowExtensions
+	}
+	return r, true
+}
+
+func (ft *fetcher) fetchOpenSky(kind, airport string, begin, end int64) (Sky, error) {
+	url := fmt.Sprintf("https://opensky-network.org/api/flights/%v?airport=%v&begin=%v&end=%v", kind, airport, begin, end)
+	log.Printf("%v %v URL: %v\n", airport, kind, url)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", ft.token))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	log.Printf("%v %v Response Status: %d\n", airport, kind, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("%v %v Response Body: %s\n", airport, kind, string(body))
+
+	r := Sky{}
+	if resp.StatusCode == http.StatusNotFound {
+		// OpenSky returns 404 when there are no flights in the window.
+		return r, nil
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%v: %s", resp.Status, string(body))
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// getFlightAware scrapes the FlightAware page for callsign.
+func (ft *fetcher) getFlightAware(callsign string) (FlightAwareResponse, error) {
+	ft.mu.Lock()
+	f, ok := ft.cache[callsign]
+	ft.mu.Unlock()
+	if ok {
+		log.Printf("%v: using cached FlightAware response\n", callsign)
+		return f, nil
+	}
+	if ft.rec != nil && ft.rec.replay {
+		err := ft.rec.load("flightaware", callsign, &f)
+		if err == nil {
+			ft.mu.Lock()
+			ft.cache[callsign] = f
+			ft.mu.Unlock()
+		}
+		return f, err
+	}
+
+	ft.limiter.wait()
+	url := fmt.Sprintf("https://www.flightaware.com/live/flight/%v", callsign)
+	resp, err := http.Get(url)
+	if err != nil {
+		return f, err
+	}
+	defer resp.Body.Close()
+
+	doc, err := html.Parse(resp.Body)
+	if err != nil {
+		return f, err
+	}
+	r := renderNode(doc)
+	r, err = cleanUpString(r)
+	if err != nil {
+		return f, fmt.Errorf("error cleaning %v: %w", callsign, err)
+	}
+
+	c := strings.Index(r, `"activityLog":{`)
+	r = r[c+14:]
+	r += "]}"
+	h := strings.Index(r, "adhocAvailable")
+	if h != -1 {
+		r = r[:h-16]
+	}
+
+	err = json.Unmarshal([]byte(r), &f)
+	if err != nil {
+		log.Println(err, r)
+	}
+	if ft.rec != nil {
+		if err := ft.rec.save("flightaware", callsign, f); err != nil {
+			log.Printf("%v: error recording FlightAware response: %v", callsign, err)
+		}
+	}
+
+	ft.mu.Lock()
+	ft.cache[callsign] = f
+	ft.mu.Unlock()
+	return f, nil
+}
+
+// airlineInfo returns the name and radio callsign of airline from
+// openscope if the configuration asks for them.
+func (ft *fetcher) airlineInfo(airline string) (name, telephony string) {
+	if !ft.cfg.AirlineNames {
+		return "", ""
+	}
+	return ft.openscope[airline].Name, ft.telephony[airline]
+}
+
+// distanceAllowed reports whether a departure from airport to destination
+// passes the distance filter, as far as can be told before looking it up.
+func (ft *fetcher) distanceAllowed(airport, destination string) bool {
+	if !ft.cfg.Distance.active() {
+		return true
+	}
+	nm, ok := distanceNM(coord(ft.airports, airport, nil), coord(ft.airports, destination, nil))
+	return !ok || ft.cfg.Distance.allowed(nm)
+}
+
+// checkAirline notes airline in the report if openscope doesn't know
+// about it.
+func (ft *fetcher) checkAirline(airport, airline string) {
+	if _, ok := ft.openscope[airline]; !ok {
+		ft.report.missingAirline(airport, airline)
+	}
+}
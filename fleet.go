@@ -0,0 +1,157 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// Flights at least this far (in statute miles, as FlightAware reports them)
+// are long haul, and flights shorter than shortHaulMiles are short haul, for
+// the purposes of picking a fleet.
+const (
+	longHaulMiles  = 2500
+	shortHaulMiles = 1000
+)
+
+// fleetCandidate is a fleet that contains the aircraft type being placed,
+// along with what's known about how well it fits the flight.
+type fleetCandidate struct {
+	name     string
+	priority int     // index in the configured priority list; lower is better
+	special  bool    // the fleet is for a particular airport or operator
+	fit      int     // how well the fleet's name matches the flight length
+	share    float64 // fraction of the fleet's aircraft that are the type
+}
+
+// FleetChoice records a fleet selection that couldn't be decided on its
+// merits, for the report.
+type FleetChoice struct {
+	Callsign   string   `json:"callsign"`
+	Type       string   `json:"type"`
+	Chosen     string   `json:"chosen"`
+	Candidates []string `json:"candidates"`
+}
+
+// genericFleets are the fleet names openscope uses for an airline's general
+// operations.
+var genericFleets = []string{"default", "long", "short", "medium", "domestic", "passenger", "cargo", "freight"}
+
+// getFleet returns the fleet of airline that an aircraft of type acType
+// flying distance miles most plausibly belongs to, along with every fleet
+// that contains the type, best first.
+func getFleet(ac map[string]Airlines, acType, airline string, distance int, priority []string) (fleet string, candidates []string, ambiguous bool) {
+	info := ac[airline]
+	var cands []fleetCandidate
+	for name, x := range info.Fleets {
+		total, count := 0, 0
+		for _, aircraft := range x {
+			total += aircraft.Count
+			if acType == aircraft.ICAO {
+				count += aircraft.Count
+			}
+		}
+		if count == 0 {
+			continue
+		}
+
+		c := fleetCandidate{
+			name:     name,
+			priority: len(priority),
+			special:  !slices.Contains(genericFleets, name) && !strings.EqualFold(name, acType),
+			fit:      fleetFit(name, distance),
+			share:    float64(count) / float64(total),
+		}
+		if i := slices.Index(priority, name); i != -1 {
+			c.priority = i
+		}
+		cands = append(cands, c)
+	}
+	if len(cands) == 0 {
+		return "", nil, false
+	}
+
+	slices.SortFunc(cands, func(a, b fleetCandidate) int {
+		if a.priority != b.priority {
+			return cmp.Compare(a.priority, b.priority)
+		}
+		if a.special != b.special {
+			return cmp.Compare(boolInt(a.special), boolInt(b.special))
+		}
+		if a.fit != b.fit {
+			return cmp.Compare(b.fit, a.fit)
+		}
+		if a.share != b.share {
+			return cmp.Compare(b.share, a.share)
+		}
+		return strings.Compare(a.name, b.name)
+	})
+	for _, c := range cands {
+		candidates = append(candidates, c.name)
+	}
+	if len(cands) > 1 {
+		a, b := cands[0], cands[1]
+		ambiguous = a.priority == b.priority && a.special == b.special && a.fit == b.fit && a.share == b.share
+	}
+	return cands[0].name, candidates, ambiguous
+}
+
+// fleetFit scores how well a fleet's name suits a flight of distance miles.
+func fleetFit(name string, distance int) int {
+	name = strings.ToLower(name)
+	long, short := strings.Contains(name, "long"), strings.Contains(name, "short")
+	switch {
+	case distance <= 0:
+		return 0
+	case distance >= longHaulMiles && long, distance < shortHaulMiles && short:
+		return 1
+	case distance >= longHaulMiles && short, distance < shortHaulMiles && long:
+		return -1
+	default:
+		return 0
+	}
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// TypeEquivalents groups aircraft types into families of near-identical
+// variants, e.g. B38M and B39M.
+type TypeEquivalents struct {
+	Families [][]string `json:"families"`
+}
+
+// loadTypeEquivalents reads the type families at path in the resources
+// folder. Without the file, no substitutions are made.
+func loadTypeEquivalents(path string) (TypeEquivalents, error) {
+	return loadJSONResource[TypeEquivalents](path, true)
+}
+
+// alternatives returns the types that may stand in for acType.
+func (t TypeEquivalents) alternatives(acType string) []string {
+	var alts []string
+	for _, family := range t.Families {
+		if !slices.Contains(family, acType) {
+			continue
+		}
+		for _, ty := range family {
+			if ty != acType && !slices.Contains(alts, ty) {
+				alts = append(alts, ty)
+			}
+		}
+	}
+	return alts
+}
+
+// TypeSubstitution records a departure whose type wasn't in the airline's
+// fleets and was placed using an equivalent type instead.
+type TypeSubstitution struct {
+	Callsign string `json:"callsign"`
+	Type     string `json:"type"`
+	UsedType string `json:"used_type"`
+	Fleet    string `json:"fleet"`
+}
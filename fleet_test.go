@@ -0,0 +1,76 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGetFleet(t *testing.T) {
+	ac := map[string]Airlines{
+		"DAL": {Fleets: map[string][]FleetAircraft{
+			"default": {{"B738", 10}, {"A321", 10}, {"B772", 2}},
+			"long":    {{"B772", 8}, {"A359", 8}},
+			"short":   {{"B738", 5}, {"CRJ9", 20}},
+			"tncm":    {{"B738", 1}},
+		}},
+		"UAL": {Fleets: map[string][]FleetAircraft{
+			"domestic":  {{"B739", 10}},
+			"passenger": {{"B739", 10}},
+		}},
+	}
+	tests := []struct {
+		acType, airline string
+		distance        int
+		priority        []string
+		want            string
+		candidates      []string
+		ambiguous       bool
+	}{
+		// The fleet where the type makes up more of the aircraft wins
+		// when nothing else decides.
+		{"B772", "DAL", 0, nil, "long", []string{"long", "default"}, false},
+		// Long and short fleets are preferred for flights of that length.
+		{"B738", "DAL", 500, nil, "short", []string{"short", "default", "tncm"}, false},
+		{"B738", "DAL", 3000, nil, "default", []string{"default", "short", "tncm"}, false},
+		{"B772", "DAL", 500, nil, "default", []string{"default", "long"}, false},
+		// Special fleets lose to generic ones, but the priority list wins
+		// over everything.
+		{"B738", "DAL", 1500, nil, "default", []string{"default", "short", "tncm"}, false},
+		{"B738", "DAL", 500, []string{"tncm"}, "tncm", []string{"tncm", "short", "default"}, false},
+		// Otherwise identical fleets are picked by name, and flagged.
+		{"B739", "UAL", 0, nil, "domestic", []string{"domestic", "passenger"}, true},
+		{"B739", "UAL", 0, []string{"passenger"}, "passenger", []string{"passenger", "domestic"}, false},
+
+		{"A388", "DAL", 0, nil, "", nil, false},
+		{"B738", "AAL", 0, nil, "", nil, false},
+	}
+	for _, test := range tests {
+		fleet, candidates, ambiguous := getFleet(ac, test.acType, test.airline, test.distance, test.priority)
+		if fleet != test.want || !slices.Equal(candidates, test.candidates) || ambiguous != test.ambiguous {
+			t.Errorf("getFleet(%v, %v, %v, %v) = %q, %v, %v; want %q, %v, %v", test.acType, test.airline,
+				test.distance, test.priority, fleet, candidates, ambiguous, test.want, test.candidates, test.ambiguous)
+		}
+	}
+}
+
+func TestTypeAlternatives(t *testing.T) {
+	types := TypeEquivalents{Families: [][]string{
+		{"B38M", "B39M", "B738", "B739"},
+		{"A20N", "A320"},
+		{"B739", "B39M"},
+	}}
+	tests := []struct {
+		acType string
+		want   []string
+	}{
+		{"B39M", []string{"B38M", "B738", "B739"}},
+		{"B739", []string{"B38M", "B39M", "B738"}},
+		{"A320", []string{"A20N"}},
+		{"C172", nil},
+	}
+	for _, test := range tests {
+		if got := types.alternatives(test.acType); !slices.Equal(got, test.want) {
+			t.Errorf("alternatives(%v) = %v; want %v", test.acType, got, test.want)
+		}
+	}
+}
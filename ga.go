@@ -0,0 +1,114 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Registry maps aircraft registrations to their ICAO aircraft type.
+type Registry map[string]string
+
+// loadRegistry reads the registry at path in the resources folder. A
+// missing file just means an empty registry.
+func loadRegistry(path string) (Registry, error) {
+	reg := Registry{}
+	entries, err := loadJSONResource[map[string]string](path, true)
+	if err != nil {
+		return reg, err
+	}
+	for r, t := range entries {
+		reg[strings.ToUpper(r)] = strings.ToUpper(t)
+	}
+	return reg, nil
+}
+
+// gaPrefixes returns the registration prefixes that openscope has general
+// aviation entries for, longest first.
+func gaPrefixes(airlines map[string]Airlines) []string {
+	var prefixes []string
+	for icao, al := range airlines {
+		if strings.HasPrefix(al.Name, "General Aviation") {
+			prefixes = append(prefixes, icao)
+		}
+	}
+	slices.SortFunc(prefixes, func(a, b string) int {
+		if len(a) != len(b) {
+			return len(b) - len(a)
+		}
+		return strings.Compare(a, b)
+	})
+	return prefixes
+}
+
+// registrationPrefix reports whether callsign is an aircraft registration
+// rather than an airline callsign and, if it is, returns its nationality
+// prefix.
+func registrationPrefix(callsign string, prefixes []string) (string, bool) {
+	callsign = strings.ToUpper(strings.TrimSpace(callsign))
+	if len(callsign) < 2 {
+		return "", false
+	}
+	if callsign[0] == 'N' && unicode.IsDigit(rune(callsign[1])) {
+		return "N", true
+	}
+	if len(callsign) < 4 || len(callsign) > 6 {
+		return "", false
+	}
+	for _, ch := range callsign {
+		if !unicode.IsLetter(ch) {
+			return "", false
+		}
+	}
+	for _, p := range prefixes {
+		if p == "N" || !strings.HasPrefix(callsign, p) {
+			continue
+		}
+		if len(p) > 1 {
+			return p, true
+		}
+		// A single letter matches too many airline callsigns, so the rest
+		// of the registration has to have that country's format.
+		format, ok := singleLetterFormats[p]
+		if ok && len(callsign) == len(p)+format.letters &&
+			(format.first == "" || strings.ContainsRune(format.first, rune(callsign[1]))) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// singleLetterFormats are the all-letter registration formats of countries
+// with single-letter nationality prefixes: how many letters follow the
+// prefix and, if it's restricted, what the first of them may be.
+var singleLetterFormats = map[string]struct {
+	letters int
+	first   string
+}{
+	"C": {4, "FGI"},
+	"D": {4, ""},
+	"F": {4, ""},
+	"G": {4, ""},
+	"I": {4, ""},
+	"M": {4, ""},
+	"Z": {3, ""},
+}
+
+// gaFleet picks the openscope fleet for a general aviation aircraft of type
+// acType registered under prefix.
+func gaFleet(airlines map[string]Airlines, prefix, acType, engType string) string {
+	order := []string{"lightGA", "cessna", "fastGA", "default"}
+	if strings.EqualFold(engType, "jet") {
+		order = []string{"fastGA", "cessna", "lightGA", "default"}
+	}
+	fleets := airlines[prefix].Fleets
+	for _, name := range order {
+		if slices.ContainsFunc(fleets[name], func(ac FleetAircraft) bool { return ac.ICAO == acType }) {
+			return name
+		}
+	}
+	if strings.EqualFold(engType, "jet") {
+		return "fastGA"
+	}
+	return "lightGA"
+}
@@ -0,0 +1,52 @@
+package main
+
+import "testing"
+
+func TestRegistrationPrefix(t *testing.T) {
+	prefixes := []string{"HB", "VH", "C", "D", "F", "G", "I", "M", "N", "Z"}
+	tests := []struct {
+		callsign string
+		want     string
+		ok       bool
+	}{
+		// US registrations and all-letter ones with known prefixes.
+		{"N123AB", "N", true},
+		{"N1", "N", true},
+		{"HBJAA", "HB", true},
+		{"VHABC", "VH", true},
+		{"CFABC", "C", true},
+		{"CGABC", "C", true},
+		{"DEIAB", "D", true},
+		{"FGXYZ", "F", true},
+		{"GABCD", "G", true},
+		{"IABCD", "I", true},
+		{"MABCD", "M", true},
+		{"ZABC", "Z", true},
+		{" gabcd ", "G", true},
+
+		// Airline callsigns.
+		{"UAL123", "", false},
+		{"DLH4YE", "", false},
+		{"BAW12AB", "", false},
+		{"NKS123", "", false},
+
+		// All letters, but not in a single-letter prefix's format.
+		{"CKSAB", "", false},
+		{"DLHABC", "", false},
+		{"FDXA", "", false},
+		{"GTIABC", "", false},
+		{"ZZZAB", "", false},
+
+		// Unknown prefixes and other junk.
+		{"QABCD", "", false},
+		{"HBJAAAA", "", false},
+		{"N", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := registrationPrefix(tt.callsign, prefixes)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("registrationPrefix(%q) = %q, %v; want %q, %v", tt.callsign, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+)
+
+// legs returns the legs of f that are kind ("departure" or "arrival") at
+// airport, closest in time to when OpenSky saw the flight first, and leaves
+// out the ones that were cancelled, diverted or ad hoc.
+func (f FlightAwareResponse) legs(kind, airport string, seen int64) []FlightAwareFlight {
+	var legs []FlightAwareFlight
+	for _, flight := range f.Flights {
+		at := flight.Origin.Icao
+		if kind == "arrival" {
+			at = flight.Destination.Icao
+		}
+		if at != airport || flight.FlightStatus == "" || flight.Cancelled || flight.Diverted || flight.Adhoc {
+			continue
+		}
+		legs = append(legs, flight)
+	}
+	if seen != 0 {
+		off := func(flight FlightAwareFlight) int64 {
+			t := flight.departureTime()
+			if kind == "arrival" {
+				t = flight.arrivalTime()
+			}
+			if t == 0 {
+				// Legs without a time go last.
+				return 1 << 62
+			}
+			return max(t-seen, seen-t)
+		}
+		slices.SortStableFunc(legs, func(a, b FlightAwareFlight) int { return cmp.Compare(off(a), off(b)) })
+	}
+	return legs
+}
+
+// hasPlan reports whether FlightAware has any flight plan for the leg.
+func (f FlightAwareFlight) hasPlan() bool {
+	return f.FlightPlan.Route != "" || f.FlightPlan.Altitude != nil
+}
+
+// departureTime returns when the leg took off, or would have, as a Unix
+// time.
+func (f FlightAwareFlight) departureTime() int64 {
+	return firstTime(f.TakeoffTimes.Actual, f.TakeoffTimes.Estimated, f.TakeoffTimes.Scheduled,
+		f.GateDepartureTimes.Scheduled)
+}
+
+// arrivalTime is departureTime for landing.
+func (f FlightAwareFlight) arrivalTime() int64 {
+	return firstTime(f.LandingTimes.Actual, f.LandingTimes.Estimated, f.LandingTimes.Scheduled,
+		f.GateArrivalTimes.Scheduled)
+}
+
+// firstTime returns the first of times that's a Unix time. FlightAware
+// gives times as numbers, or null if they're unknown.
+func firstTime(times ...any) int64 {
+	for _, t := range times {
+		switch t := t.(type) {
+		case int:
+			if t > 0 {
+				return int64(t)
+			}
+		case float64:
+			if t > 0 {
+				return int64(t)
+			}
+		}
+	}
+	return 0
+}
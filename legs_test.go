@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// testLeg returns a leg from origin to dest that took off at takeoff and
+// landed an hour later, or has no times if takeoff is zero, with its name as
+// the route so that it can be told apart. extra is added to its JSON, e.g.
+// `"cancelled": true`.
+func testLeg(t *testing.T, name, origin, dest string, takeoff int, extra string) FlightAwareFlight {
+	landing := "null"
+	if takeoff != 0 {
+		landing = fmt.Sprint(takeoff + 3600)
+	}
+	s := fmt.Sprintf(`{"origin": {"icao": %q}, "destination": {"icao": %q}, "flightStatus": "arrived",
+		"takeoffTimes": {"actual": %v}, "landingTimes": {"actual": %v}, "flightPlan": {"route": %q}`,
+		origin, dest, takeoff, landing, name)
+	if extra != "" {
+		s += ", " + extra
+	}
+	var f FlightAwareFlight
+	if err := json.Unmarshal([]byte(s+"}"), &f); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestLegs(t *testing.T) {
+	const day = 24 * 60 * 60
+	f := FlightAwareResponse{Flights: []FlightAwareFlight{
+		testLeg(t, "yesterday", "KJFK", "KLAX", 1000, ""),
+		testLeg(t, "today", "KJFK", "KLAX", 1000+day, ""),
+		testLeg(t, "return", "KLAX", "KJFK", 1000+day/2, ""),
+		testLeg(t, "tomorrow", "KJFK", "KLAX", 1000+2*day, ""),
+		testLeg(t, "cancelled", "KJFK", "KLAX", 1000+day, `"cancelled": true`),
+		testLeg(t, "diverted", "KJFK", "KLAX", 1000+day, `"diverted": true`),
+		testLeg(t, "adhoc", "KJFK", "KLAX", 1000+day, `"adhoc": true`),
+		testLeg(t, "unknown", "KJFK", "KLAX", 0, ""),
+		testLeg(t, "nostatus", "KJFK", "KLAX", 1000+day, `"flightStatus": ""`),
+	}}
+	tests := []struct {
+		kind, airport string
+		seen          int64
+		want          []string
+	}{
+		// Closest to when OpenSky saw the flight first, with legs that
+		// don't have a time last.
+		{"departure", "KJFK", 1000 + day + 60, []string{"today", "tomorrow", "yesterday", "unknown"}},
+		{"departure", "KJFK", 1000 + 2*day - 60, []string{"tomorrow", "today", "yesterday", "unknown"}},
+		{"departure", "KJFK", 900, []string{"yesterday", "today", "tomorrow", "unknown"}},
+		// Without a time, FlightAware's order.
+		{"departure", "KJFK", 0, []string{"yesterday", "today", "tomorrow", "unknown"}},
+		// Arrivals go by landing time.
+		{"arrival", "KJFK", 1000 + day/2 + 3600, []string{"return"}},
+		{"arrival", "KLAX", 1000 + 3600, []string{"yesterday", "today", "tomorrow", "unknown"}},
+		{"departure", "KLGA", 1000, nil},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, leg := range f.legs(test.kind, test.airport, test.seen) {
+			got = append(got, leg.FlightPlan.Route)
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("legs(%v, %v, %v) = %v; want %v", test.kind, test.airport, test.seen, got, test.want)
+		}
+	}
+}
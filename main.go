@@ -8,13 +8,14 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
 	"net/url"
 	"os"
-	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unicode"
 
@@ -27,6 +28,9 @@ import (
 type DepartureAirline struct {
 	ICAO  string `json:"icao"`
 	Fleet string `json:"fleet,omitempty"`
+	// Name and Callsign are only included with -airline-names.
+	Name     string `json:"name,omitempty"`
+	Callsign string `json:"callsign,omitempty"`
 }
 
 type Departure struct {
@@ -37,16 +41,33 @@ type Departure struct {
 	Airlines            []DepartureAirline `json:"airlines"`
 	Scratchpad          string             `json:"scratchpad,omitempty"`
 	SecondaryScratchpad string             `json:"secondary_scratchpad,omitempty"`
+	// Distance is the great-circle distance to the destination in nautical
+	// miles; vice ignores it and Haul.
+	Distance int    `json:"distance,omitempty"`
+	Haul     string `json:"haul,omitempty"`
+
+	details DepartureDetails
 }
 
 type CallsignOutput struct {
 	Airline      string
 	ICAOCallsign string
+	// GA is set for general aviation flights, whose callsign is their
+	// registration and whose Airline is its nationality prefix.
+	GA bool
+	// Destination is OpenSky's estimate of where the flight went, if it
+	// has one.
+	Destination string
+	// FirstSeen is when OpenSky first saw the flight, as a Unix time, for
+	// picking the right leg of its flight number.
+	FirstSeen int64
 }
 
-var airport string
-
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(runValidate(os.Args[2:]))
+	}
+
 	// Load .env file
 	err := godotenv.Load()
 	if err != nil {
@@ -61,178 +82,483 @@ func main() {
 	log.SetOutput(p)
 
 	// Define flags
+	configFlag := flag.String("config", "", "facility configuration file")
 	airportPrintFlag := flag.String("airport", "", "airport to fetch")
-	amountPrintFlag := flag.String("amount", "", "amount of aircraft")
+	airportsFlag := flag.String("airports", "", "comma-separated list of airports to fetch")
+	allowUnknownFlag := flag.Bool("allow-unknown-airports", false, "fetch ICAO codes that aren't in airports.json")
+	amountPrintFlag := flag.String("amount", "", "number of usable departures and arrivals to produce per airport")
+	departuresFlag := flag.Int("departures", 0, "number of usable departures to produce per airport, instead of -amount")
+	arrivalsFlag := flag.Int("arrivals", 0, "number of arrivals to produce per airport, instead of -amount")
+	onlyFlag := flag.String("only", "", "fetch only \"departures\" or \"arrivals\"")
+	aggregateFlag := flag.Bool("aggregate-arrivals", false, "write arrivals as airlines and counts per origin airport")
+	groupFlag := flag.Bool("group-arrivals", false, "aggregate arrivals separately for each gate they come in through")
+	minDistanceFlag := flag.String("min-distance", "", "skip departures to destinations closer than this, e.g. 300nm")
+	maxDistanceFlag := flag.String("max-distance", "", "skip departures to destinations further than this, e.g. 2500mi")
+	detailsFlag := flag.Bool("details", false, "also write each departure's flight plan and aircraft details")
+	splitFlag := flag.Bool("split-arrivals", false, "write each gate's arrivals to its own file")
+	maxAttemptsFlag := flag.Int("max-attempts", 0, "most FlightAware lookups to make per airport; 0 for no limit")
+	gaFlag := flag.Bool("ga", false, "include general aviation flights")
+	viceFlag := flag.String("vice-resources", "", "vice installation to read the airline database from")
+	namesFlag := flag.Bool("airline-names", false, "include airline names and radio callsigns in the output")
+	exitsFlag := flag.String("exits", "", "comma-separated list of exits to keep departures through")
+	destinationsFlag := flag.String("destinations", "", "comma-separated list of destinations to keep departures to, e.g. K*")
+	excludeDestinationsFlag := flag.String("exclude-destinations", "", "comma-separated list of destinations to skip departures to, e.g. C*")
+	wakeFlag := flag.String("wake", "", "comma-separated list of wake categories to keep departures in: J, H, B, L or S")
+	recatFlag := flag.String("recat", "", "comma-separated list of RECAT categories to keep departures in: A to F")
+	enginesFlag := flag.String("engines", "", "comma-separated list of engine classes to keep departures with: jet, turboprop or piston")
+	discardFlag := flag.Bool("discard-mismatches", false, "discard departures whose flight plan doesn't match what OpenSky saw")
+	toleranceFlag := flag.String("mismatch-tolerance", "", "how far OpenSky's and FlightAware's departure times may differ, e.g. 90m")
+	samplingFlag := flag.String("sampling", "", "order to look callsigns up in: first, random, airline, destination or proportional")
+	seedFlag := flag.Int64("seed", 0, "random seed for sampling; 0 picks one")
+	recordFlag := flag.String("record", "", "folder to record the OpenSky and FlightAware responses in")
+	replayFlag := flag.String("replay", "", "folder of recorded responses to use instead of fetching")
+	altitudeFlag := flag.String("altitude-check", "", "check cruise altitudes against the direction of flight: \"flag\" or \"correct\"")
 	flag.Parse()
-	if *airportPrintFlag == "" {
+
+	cfg := defaultConfig()
+	if *configFlag != "" {
+		cfg, err = loadConfig(*configFlag)
+		if err != nil {
+			log.Fatalf("Error loading config: %v", err)
+		}
+	}
+	if *airportPrintFlag != "" {
+		cfg.Airport, cfg.Airports = *airportPrintFlag, nil
+	}
+	if *amountPrintFlag != "" {
+		cfg.Amount, err = strconv.Atoi(*amountPrintFlag)
+		if err != nil {
+			log.Fatalf("%v is not an intiger", *amountPrintFlag)
+		}
+	}
+	if *departuresFlag != 0 {
+		cfg.Departures = *departuresFlag
+	}
+	if *arrivalsFlag != 0 {
+		cfg.Arrivals = *arrivalsFlag
+	}
+	if *onlyFlag != "" {
+		cfg.Only = *onlyFlag
+	}
+	if err := cfg.checkOnly(); err != nil {
+		log.Fatal(err)
+	}
+	if *aggregateFlag {
+		cfg.AggregateArrivals = true
+	}
+	if *groupFlag {
+		cfg.GroupArrivals = true
+	}
+	if *minDistanceFlag != "" {
+		cfg.Distance.Min = *minDistanceFlag
+	}
+	if *maxDistanceFlag != "" {
+		cfg.Distance.Max = *maxDistanceFlag
+	}
+	if _, _, err := cfg.Distance.limits(); err != nil {
+		log.Fatal(err)
+	}
+	if *detailsFlag {
+		cfg.Details = true
+	}
+	if *splitFlag {
+		cfg.SplitArrivals = true
+	}
+	if err := checkCornerPosts(cfg.CornerPosts); err != nil {
+		log.Fatal(err)
+	}
+	if *maxAttemptsFlag != 0 {
+		cfg.MaxAttempts = *maxAttemptsFlag
+	}
+	if *gaFlag {
+		cfg.GA = true
+	}
+	if *viceFlag != "" {
+		cfg.ViceResources = *viceFlag
+	}
+	if *namesFlag {
+		cfg.AirlineNames = true
+	}
+	if *altitudeFlag != "" {
+		cfg.AltitudeCheck = *altitudeFlag
+	}
+	if err := validAltitudeCheck(cfg.AltitudeCheck); err != nil {
+		log.Fatal(err)
+	}
+	if *samplingFlag != "" {
+		cfg.Sampling = *samplingFlag
+	}
+	if err := validSampling(cfg.Sampling); err != nil {
+		log.Fatal(err)
+	}
+	if *seedFlag != 0 {
+		cfg.Seed = *seedFlag
+	}
+	var rec *recording
+	if *recordFlag != "" && *replayFlag != "" {
+		log.Fatal("-record and -replay can't be used together")
+	} else if *recordFlag != "" {
+		rec = &recording{dir: *recordFlag}
+	} else if *replayFlag != "" {
+		rec = &recording{dir: *replayFlag, replay: true}
+		if err := rec.restore(&cfg); err != nil {
+			log.Fatalf("Error reading recording: %v", err)
+		}
+	}
+	if cfg.Seed == 0 {
+		cfg.Seed = time.Now().UnixNano()
+	}
+	log.Printf("Sampling with seed %v", cfg.Seed)
+	if err := cfg.pinWindow(); err != nil {
+		log.Fatalf("Bad time window: %v", err)
+	}
+	if rec != nil && !rec.replay {
+		if err := rec.pin(cfg); err != nil {
+			log.Fatalf("Error recording: %v", err)
+		}
+	}
+	if *exitsFlag != "" {
+		cfg.Routes.Exits = splitList(*exitsFlag)
+	}
+	if *destinationsFlag != "" {
+		cfg.Routes.Destinations = splitList(*destinationsFlag)
+	}
+	if *excludeDestinationsFlag != "" {
+		cfg.Routes.ExcludeDestinations = splitList(*excludeDestinationsFlag)
+	}
+	if err := cfg.Routes.check(); err != nil {
+		log.Fatal(err)
+	}
+	if *wakeFlag != "" {
+		cfg.Wake.Wake = splitList(*wakeFlag)
+	}
+	if *recatFlag != "" {
+		cfg.Wake.RECAT = splitList(*recatFlag)
+	}
+	if *enginesFlag != "" {
+		cfg.Wake.Engines = splitList(*enginesFlag)
+	}
+	if err := cfg.Wake.check(); err != nil {
+		log.Fatal(err)
+	}
+	if *discardFlag {
+		cfg.CrossCheck.Discard = true
+	}
+	if *toleranceFlag != "" {
+		cfg.CrossCheck.Tolerance = *toleranceFlag
+	}
+	if _, err := cfg.CrossCheck.tolerance(); err != nil {
+		log.Fatal(err)
+	}
+	if *airportsFlag != "" {
+		cfg.Airports = strings.Split(*airportsFlag, ",")
+	}
+	if len(cfg.airports()) == 0 {
 		flag.Usage()
 		os.Exit(1)
 	}
-	airport = *airportPrintFlag
-	var amount int
-	if *amountPrintFlag == "" {
-		amount = 50
-	} else {
-		amount, err = strconv.Atoi(*amountPrintFlag)
-		if err != nil {
-			log.Fatalf("%v is not an intiger", amount)
+	if *allowUnknownFlag {
+		cfg.AllowUnknownAirports = true
+	}
+	if err := initResources(cfg.Resources, cfg.ViceResources); err != nil {
+		log.Fatalf("Error finding resources: %v", err)
+	}
+	airportDB, err := loadAirports("airports.json")
+	if err != nil {
+		log.Fatalf("Error loading airports: %v", err)
+	}
+	var unknown []string
+	if cfg.Airports, unknown, err = resolveAirports(cfg.airports(), airportDB); err != nil {
+		fmt.Println(err)
+		log.Fatal(err)
+	}
+	for _, icao := range unknown {
+		if !cfg.AllowUnknownAirports {
+			fatalf("%v isn't in %v. Check the code, add the airport to the file, or use -allow-unknown-airports to fetch it anyway",
+				icao, resourceName("airports.json"))
 		}
+		msg := fmt.Sprintf("Warning: %v isn't in %v, so its location isn't known; fetching it anyway",
+			icao, resourceName("airports.json"))
+		fmt.Println(msg)
+		log.Print(msg)
 	}
+	cfg.Airport = ""
 
-	getDepartureCallsigns2(*airportPrintFlag, amount)
+	// Check the rules before anything is fetched, rather than finding out
+	// they're broken once the requests have been spent.
+	scRules, err := cfg.loadScratchpadRules()
+	if err != nil {
+		fatalf("Error loading scratchpad rules (run \"validate\" for details): %v", err)
+	}
+	exceptions, err := cfg.loadExitExceptions()
+	if err != nil {
+		fatalf("Error loading exit exceptions (run \"validate\" for details): %v", err)
+	}
+
+	getDepartureCallsigns2(cfg, rec, airportDB, scRules, exceptions)
+}
+
+// fatalf logs the error and prints it, since the log only goes to
+// log.txt, then exits.
+func fatalf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	log.Fatalf(format, args...)
 }
 
-func flightAwareNonsenseDepartures(callsigns []CallsignOutput, amount int, bar *mpb.Bar) {
+// flightAwareNonsenseDepartures looks up departure callsigns from src on
+// FlightAware until it has amount usable departures, src runs out, or
+// cfg.MaxAttempts lookups have been made.
+func (ft *fetcher) flightAwareNonsenseDepartures(airport string, src *flightSource, lookupBar, bar progressBar) {
 	defer wg.Done()
+	cfg, amount := ft.cfg, ft.cfg.departureAmount()
 	departures := []Departure{}
-	scRules := ScratchpadRules{}
-	var scratchpads bool = true
-	file, err := os.ReadFile("resources/scratchpad-rules.json")
-	if err != nil {
-		scratchpads = false
-	}
-	json.Unmarshal(file, &scRules)
-Callsign:
-	for _, aircraft := range callsigns {
-		url := fmt.Sprintf("https://www.flightaware.com/live/flight/%v", aircraft.ICAOCallsign)
-		resp, err := http.Get(url)
-		if err != nil {
-			panic(err)
-		}
-		defer resp.Body.Close()
 
-		doc, err := html.Parse(resp.Body)
-		if err != nil {
-			panic(err)
+	rng := airportRand(cfg.Seed, airport)
+	seen := make(map[string]bool)
+	attempts, gathered := 0, 0
+Batch:
+	for len(departures) < amount {
+		flights, ok := src.next()
+		if !ok {
+			log.Printf("%v: ran out of departure callsigns after %v lookups", airport, attempts)
+			break
 		}
-		r := renderNode(doc)
-		r, err = cleanUpString(r)
-		if err != nil {
-			log.Printf("error cleaning %v\n", aircraft.ICAOCallsign)
-			continue
+		var callsigns []CallsignOutput
+		for _, c := range ft.departureCallsigns(airport, flights) {
+			if !seen[c.ICAOCallsign] {
+				seen[c.ICAOCallsign] = true
+				callsigns = append(callsigns, c)
+			}
 		}
-
-		c := strings.Index(r, `"activityLog":{`)
-		r = r[c+14:]
-		r += "]}"
-		f := FlightAwareResponse{}
-		h := strings.Index(r, "adhocAvailable")
-		if h != -1 {
-			r = r[:h-16]
+		gathered += len(callsigns)
+		log.Printf("%v: amount of callsigns: %v", airport, gathered)
+		// Keep the total above what's been looked up so far, so that the bar
+		// doesn't finish before the window is extended.
+		if cfg.MaxAttempts > 0 && gathered >= cfg.MaxAttempts {
+			lookupBar.SetTotal(int64(cfg.MaxAttempts), false)
+		} else {
+			lookupBar.SetTotal(int64(gathered+1), false)
 		}
 
-		err = json.Unmarshal([]byte(r), &f)
-		if err != nil {
-			log.Println(err, r)
+		for _, aircraft := range sampleCallsigns(callsigns, cfg.Sampling, rng) {
+			if cfg.MaxAttempts > 0 && attempts == cfg.MaxAttempts {
+				log.Printf("%v: giving up after %v lookups", airport, attempts)
+				break Batch
+			}
+			attempts++
+			f, err := ft.getFlightAware(aircraft.ICAOCallsign)
+			lookupBar.IncrBy(1)
+			if err != nil {
+				log.Printf("%v: %v\n", aircraft.ICAOCallsign, err)
+				continue
+			}
+			d, ok := ft.departure(airport, aircraft, f, ft.scRules, ft.exceptions)
+			if !ok {
+				continue
+			}
+			departures = append(departures, d)
+			ft.report.category(airport, d.details)
+			bar.IncrBy(1)
+			if len(departures) == amount {
+				break Batch
+			}
 		}
-		openscope, _ := parseAirlines()
-
-		for _, flight := range f.Flights {
-
-			if flight.FlightStatus != "" {
-
-				d := Departure{}
-				fleet := getFleet(openscope, flight.Aircraft.Type, aircraft.Airline)
-				if fleet == "" {
-					log.Printf("%v: fleet nil for %v", aircraft.ICAOCallsign, flight.AircraftType)
-					continue Callsign
-				}
-				d.Airlines = []DepartureAirline{
-					DepartureAirline{
-						ICAO:  aircraft.Airline,
-						Fleet: fleet,
-					},
-				}
-
-				if flight.FlightPlan.Altitude == nil {
-					log.Printf("%v altitude nil.", aircraft.ICAOCallsign)
-					continue
-				}
-
-				d.Altitude = int(flight.FlightPlan.Altitude.(float64) * 100)
-				d.Destination = flight.Destination.Icao
-				d.Route = flight.FlightPlan.Route
-				waypointArray := strings.Split(flight.FlightPlan.Route, " ")
-				if flight.Origin.Icao != airport && flight.Destination.Icao == airport {
-					d.Destination = flight.Origin.Icao
-				}
+	}
+	lookupBar.finish(attempts, 0)
+	bar.finish(len(departures), amount)
+	ft.report.departures(airport, amount, len(departures), attempts)
+	if len(departures) <= 0 {
+		log.Printf("%v: no departure aircraft could be generated.", airport)
+	}
+	if err := writeOutput(cfg.outputPath(cfg.Output.Departures, airport), departures); err != nil {
+		panic(err)
+	}
+	if cfg.Details {
+		details := []DepartureDetails{}
+		for _, d := range departures {
+			details = append(details, d.details)
+		}
+		if err := writeOutput(cfg.outputPath(cfg.Output.Details, airport), details); err != nil {
+			panic(err)
+		}
+	}
+	log.Printf("%v: departures done.", airport)
+}
 
-				if d.Route == "" {
-					log.Printf("%v bad route. %v", d.Route, aircraft.ICAOCallsign)
-					continue
-				}
-				if unicode.IsDigit(rune(waypointArray[0][len(waypointArray[0])-1])) {
-					waypointArray = waypointArray[1:]
-				}
-				d.Exit = waypointArray[0]
-				contents, err := os.ReadFile("resources/exit-exeptions.json")
-				if err == nil {
-					j := exitExeptions{}
-					err = json.Unmarshal(contents, &j)
-					if err == nil {
-						for _, exeptions := range j {
-							if exeptions.FoundExit == d.Exit {
-								for _, fix := range waypointArray {
-									if slices.Contains(exeptions.ActualExit, fix) {
-										d.Exit = fix
-									}
-								}
-							}
-						}
-					} else {
-						log.Println("Error unmarshalling exit-exeptions.json")
-					}
-				}
-				log.Printf("%v. %v\n", aircraft.ICAOCallsign, d)
-				if scratchpads {
-					for _, rule := range scRules.Rules {
-						if rule.Exit == d.Exit {
-							if rule.Scratchpad != "" {
-								d.Scratchpad = rule.Scratchpad
-							}
-							if rule.SecondaryScratchpad != "" {
-								d.Scratchpad = rule.SecondaryScratchpad
-							}
-						}
+// departure returns the departure from airport for aircraft from its
+// FlightAware flights, or ok false if none of them are usable.
+func (ft *fetcher) departure(airport string, aircraft CallsignOutput, f FlightAwareResponse,
+	scRules ScratchpadRules, exceptions exitExeptions) (Departure, bool) {
+	cfg := ft.cfg
+	// Only the leg closest to when OpenSky saw the flight is used; any
+	// other would be a different flight with the same number.
+	legs := f.legs("departure", airport, aircraft.FirstSeen)
+	if len(legs) == 0 {
+		log.Printf("%v: no leg departing %v", aircraft.ICAOCallsign, airport)
+		return Departure{}, false
+	}
+	flight := legs[0]
+	if !flight.hasPlan() {
+		log.Printf("%v: no flight plan for the leg departing %v", aircraft.ICAOCallsign, airport)
+		return Departure{}, false
+	}
+	d := Departure{}
+	var fleet string
+	// A type substitution or ambiguous fleet choice is only reported
+	// once the departure is known to be used.
+	var substitution *TypeSubstitution
+	var choice *FleetChoice
+	acType := flight.Aircraft.Type
+	if aircraft.GA && acType == "" {
+		acType = ft.registry[aircraft.ICAOCallsign]
+	}
+	// flown is the type that flew, which acType may be substituted for.
+	flown := acType
+	if aircraft.GA {
+		if acType == "" {
+			log.Printf("%v: unknown aircraft type", aircraft.ICAOCallsign)
+			return Departure{}, false
+		}
+		fleet = gaFleet(ft.openscope, aircraft.Airline, acType, flight.Aircraft.TypeDetails.EngType)
+	} else {
+		var candidates []string
+		var ambiguous bool
+		fleet, candidates, ambiguous = getFleet(ft.openscope, acType, aircraft.Airline,
+			flight.FlightPlan.DirectDistance, cfg.FleetPriority)
+		if fleet == "" {
+			// Try a near-identical type (e.g. B38M for a B39M)
+			// before giving up on the flight.
+			for _, alt := range ft.types.alternatives(acType) {
+				fleet, candidates, ambiguous = getFleet(ft.openscope, alt, aircraft.Airline,
+					flight.FlightPlan.DirectDistance, cfg.FleetPriority)
+				if fleet != "" {
+					substitution = &TypeSubstitution{
+						Callsign: aircraft.ICAOCallsign,
+						Type:     acType,
+						UsedType: alt,
+						Fleet:    fleet,
 					}
+					acType = alt
+					break
 				}
-				departures = append(departures, d)
-				break
 			}
 		}
-		if amount <= len(departures) {
-			log.Println("break")
-			bar.IncrBy(1)
-			break
-		} else {
-			log.Printf("no break. len: %v. Departures: %v\n", len(departures), departures)
+		if ambiguous {
+			choice = &FleetChoice{
+				Callsign:   aircraft.ICAOCallsign,
+				Type:       acType,
+				Chosen:     fleet,
+				Candidates: candidates,
+			}
 		}
-		time.Sleep(15 * time.Second)
-		bar.IncrBy(1)
-		log.Println("Increment done")
 	}
-	if len(departures) <= 0 {
-		log.Println("No departure aircraft could be generated.")
+	var name, telephony string
+	if !aircraft.GA {
+		name, telephony = ft.airlineInfo(aircraft.Airline)
+	}
+	if fleet == "" {
+		log.Printf("%v: fleet nil for %v", aircraft.ICAOCallsign, flight.AircraftType)
+		return Departure{}, false
 	}
-	f, err := json.MarshalIndent(departures, "", "    ")
+	info := ft.classify(flown, flight.Aircraft.Heavy, flight.Aircraft.TypeDetails.EngType,
+		flight.Aircraft.TypeDetails.EngCount)
+	if !cfg.Wake.allowed(info) {
+		log.Printf("%v: skipping %v departure (wake %v, %v)", aircraft.ICAOCallsign, flown, info.Wake, info.Engine)
+		return Departure{}, false
+	}
+	d.Airlines = []DepartureAirline{
+		DepartureAirline{
+			ICAO:     aircraft.Airline,
+			Fleet:    fleet,
+			Name:     name,
+			Callsign: telephony,
+		},
+	}
+
+	alt, err := parseAltitude(flight.FlightPlan.Altitude)
 	if err != nil {
-		panic(err)
+		log.Printf("%v: %v", aircraft.ICAOCallsign, err)
+		return Departure{}, false
+	}
+	alt, altitudeProblem, ok := ft.checkAltitude(aircraft.ICAOCallsign, flight.Destination.Icao, alt,
+		coord(ft.airports, flight.Origin.Icao, flight.Origin.Coord),
+		coord(ft.airports, flight.Destination.Icao, flight.Destination.Coord))
+	if !ok {
+		log.Printf("%v: dropped for altitude %v", aircraft.ICAOCallsign, alt)
+		ft.report.altitudeProblem(airport, *altitudeProblem)
+		return Departure{}, false
 	}
-	e, _ := os.Create("departures.json")
-	e.Write(f)
-	log.Println("Departures done.")
-}
 
-func getFleet(ac map[string]Airlines, acType, airline string) string {
-	info := ac[airline]
-	for fleet, x := range info.Fleets {
-		for _, aircraft := range x {
-			if acType == aircraft.ICAO {
-				return fleet
-			}
+	d.Altitude = alt
+	d.Destination = flight.Destination.Icao
+	d.Route = flight.FlightPlan.Route
+	waypointArray := strings.Split(flight.FlightPlan.Route, " ")
+
+	if !cfg.Routes.destinationAllowed(d.Destination) {
+		log.Printf("%v: skipping departure to %v", aircraft.ICAOCallsign, d.Destination)
+		return Departure{}, false
+	}
+	from, to := coord(ft.airports, flight.Origin.Icao, flight.Origin.Coord),
+		coord(ft.airports, flight.Destination.Icao, flight.Destination.Coord)
+	if nm, ok := distanceNM(from, to); ok {
+		d.Distance, d.Haul = int(math.Round(nm)), haul(nm)
+		if !cfg.Distance.allowed(nm) {
+			log.Printf("%v: skipping %vnm departure", aircraft.ICAOCallsign, d.Distance)
+			return Departure{}, false
 		}
+	} else if cfg.Distance.active() {
+		log.Printf("%v: skipping departure to %v, which is an unknown distance away", aircraft.ICAOCallsign, d.Destination)
+		return Departure{}, false
+	}
+	if d.Route == "" {
+		log.Printf("%v bad route. %v", d.Route, aircraft.ICAOCallsign)
+		return Departure{}, false
+	}
+	if unicode.IsDigit(rune(waypointArray[0][len(waypointArray[0])-1])) {
+		waypointArray = waypointArray[1:]
+	}
+	d.Exit = waypointArray[0]
+	d.Exit = applyExitExceptions(exceptions, d.Exit, waypointArray)
+	if !cfg.Routes.exitAllowed(d.Exit) {
+		log.Printf("%v: skipping departure via %v", aircraft.ICAOCallsign, d.Exit)
+		return Departure{}, false
+	}
+	if !ft.crossCheck(airport, aircraft, flight) {
+		return Departure{}, false
+	}
+	log.Printf("%v. %v\n", aircraft.ICAOCallsign, d)
+	applyScratchpadRules(scRules, &d)
+	engines, _ := strconv.Atoi(flight.Aircraft.TypeDetails.EngCount)
+	d.details = DepartureDetails{
+		Callsign:        aircraft.ICAOCallsign,
+		Destination:     d.Destination,
+		Exit:            d.Exit,
+		Type:            flown,
+		EquipmentSuffix: equipmentSuffix(flight.AircraftType),
+		Manufacturer:    flight.Aircraft.TypeDetails.Manufacturer,
+		Model:           flight.Aircraft.TypeDetails.Model,
+		EngineType:      flight.Aircraft.TypeDetails.EngType,
+		EngineCount:     engines,
+		Altitude:        d.Altitude,
+		Speed:           flight.FlightPlan.Speed,
+		ETE:             flight.FlightPlan.Ete / 60,
+		DirectDistance:  flight.FlightPlan.DirectDistance,
+		Distance:        d.Distance,
+		Haul:            d.Haul,
+		Wake:            info.Wake,
+		RECAT:           info.RECAT,
+		Engine:          info.Engine,
 	}
-	return ""
+	if substitution != nil {
+		ft.report.substitution(airport, *substitution)
+	}
+	if choice != nil {
+		ft.report.ambiguousFleet(airport, *choice)
+	}
+	if altitudeProblem != nil {
+		ft.report.altitudeProblem(airport, *altitudeProblem)
+	}
+	return d, true
 }
 
 func renderNode(node *html.Node) string {
@@ -302,167 +628,227 @@ func getAccessToken() (string, error) {
 	return tokenResp.AccessToken, nil
 }
 
-func getDepartureCallsigns2(airport string, amount int) {
-
-	// passed wg will be accounted at p.Wait() call
-	p := mpb.New(mpb.WithWaitGroup(&wg))
-	total, numGoBars := amount, 2
-	wg.Add(numGoBars)
-	fetchBar := p.AddBar(int64(total),
-		mpb.PrependDecorators(
-			decor.Name("Fetch Callsigns"),
-			decor.Percentage(decor.WCSyncSpace),
-		),
-		mpb.AppendDecorators(
-			decor.OnComplete(decor.Elapsed(decor.ET_STYLE_MMSS), "Finished!"),
-		),
-	)
-	departureBar := p.AddBar(int64(total),
-		mpb.PrependDecorators(
-			decor.Name("Fetch Departures"),
-			decor.Percentage(decor.WCSyncSpace),
-		),
-		mpb.AppendDecorators(
-			decor.OnComplete(decor.Elapsed(decor.ET_STYLE_MMSS), "Finished!"),
-		),
-	)
-	arrivalBar := p.AddBar(int64(total),
-		mpb.PrependDecorators(
-			decor.Name("Fetch Arrivals"),
-			decor.Percentage(decor.WCSyncSpace),
-		),
-		mpb.AppendDecorators(
-			decor.OnComplete(
-				decor.EwmaETA(decor.ET_STYLE_GO, 30, decor.WCSyncWidth), "Finished!",
-			),
-		),
-	)
+func getDepartureCallsigns2(cfg Config, rec *recording, airportDB map[string]Airport,
+	scRules ScratchpadRules, exceptions exitExeptions) {
+	begin, end, err := cfg.window()
+	if err != nil {
+		log.Fatalf("Bad time window: %v", err)
+	}
+	unixNow := end.Unix()
+	before := begin.Unix()
+	log.Printf("Using UTC window: begin=%v (%v), end=%v (%v)\n", before, begin, unixNow, end)
 
-	// Constrain range to at most previous day + today (UTC) to avoid crossing 3 partitions
-	nowUTC := time.Now().UTC()
-	startOfTodayUTC := time.Date(nowUTC.Year(), nowUTC.Month(), nowUTC.Day(), 0, 0, 0, 0, time.UTC)
-	startOfPrevDayUTC := startOfTodayUTC.Add(-24 * time.Hour)
-	unixNow := nowUTC.Unix()
-	before := startOfPrevDayUTC.Unix()
-	log.Printf("Using UTC window: begin=%v (%v), end=%v (%v)\n", before, time.Unix(before, 0).UTC(), unixNow, time.Unix(unixNow, 0).UTC())
-
-	// Step 1: Get access token first
-	log.Println("Getting access token...")
-	token, err := getAccessToken()
+	filters, err := cfg.loadCallsignFilters()
 	if err != nil {
-		log.Fatalf("Failed to get access token: %v", err)
+		log.Fatalf("Error loading callsign filters (run \"validate\" for details): %v", err)
 	}
-	log.Println("Access token obtained successfully")
 
-	// Step 2: Use token in Authorization header
-	url := fmt.Sprintf("https://opensky-network.org/api/flights/departure?airport=%v&begin=%v&end=%v", airport, before, unixNow)
-	log.Printf("Departure URL: %v\n", url)
-	req, err := http.NewRequest("GET", url, nil)
+	// Step 1: Get access token first (a replayed run doesn't need one)
+	var token string
+	if rec == nil || !rec.replay {
+		log.Println("Getting access token...")
+		token, err = getAccessToken()
+		if err != nil {
+			log.Fatalf("Failed to get access token: %v", err)
+		}
+		log.Println("Access token obtained successfully")
+	}
+
+	// Step 2: Fetch every airport using the same token, rate limiter,
+	// cache and progress display. The wg passed to the progress display
+	// is accounted for at Wait().
+	ft := newFetcher(cfg, token, filters, rec)
+	ft.report.Seed = cfg.Seed
+	ft.scRules, ft.exceptions = scRules, exceptions
+	ft.openscope, ft.telephony, err = parseAirlines()
 	if err != nil {
-		panic(err)
+		log.Fatalf("Error loading the airline database: %v", err)
 	}
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-	resp, err := http.DefaultClient.Do(req)
+	ft.types, err = loadTypeEquivalents("type-equivalents.json")
 	if err != nil {
-		panic(err)
+		log.Fatalf("Error loading type equivalents: %v", err)
 	}
-	log.Printf("Departure Response Status: %d\n", resp.StatusCode)
-	body, err := io.ReadAll(resp.Body)
+	ft.aircraftTypes, err = loadAircraftTypes("aircraft-types.json")
 	if err != nil {
-		panic(err)
+		log.Fatalf("Error loading aircraft types: %v", err)
+	}
+	ft.airports = airportDB
+	if cfg.GA {
+		ft.gaPrefixes = gaPrefixes(ft.openscope)
+		ft.registry, err = loadRegistry("registry.json")
+		if err != nil {
+			log.Fatalf("Error loading registry: %v", err)
+		}
 	}
-	log.Printf("Departure Response Body: %s\n", string(body))
-	r := Sky{}
+	airports := cfg.airports()
+	sides := 0
+	if cfg.departureAmount() > 0 {
+		sides++
+	}
+	if cfg.arrivalAmount() > 0 {
+		sides++
+	}
+	wg.Add(sides * len(airports))
+	for _, airport := range airports {
+		ft.fetchAirport(airport, before, unixNow)
+	}
+	ft.progress.Wait()
+
+	if err := writeOutput(cfg.Output.Report, ft.report); err != nil {
+		log.Printf("Error writing report: %v", err)
+	}
+	fmt.Print(ft.report.summary())
+}
 
-	json.Unmarshal(body, &r)
+// addBar adds a progress bar for airport to the display. The airport is
+// only included in the name when there's more than one of them.
+func (ft *fetcher) addBar(airport, name string, total int, eta bool) progressBar {
+	if len(ft.cfg.airports()) > 1 {
+		name = airport + " " + name
+	}
+	done := decor.Elapsed(decor.ET_STYLE_MMSS)
+	if eta {
+		done = decor.EwmaETA(decor.ET_STYLE_GO, 30, decor.WCSyncWidth)
+	}
+	note := &barNote{}
+	bar := ft.progress.AddBar(int64(total),
+		mpb.PrependDecorators(
+			decor.Name(name),
+			decor.Percentage(decor.WCSyncSpace),
+		),
+		mpb.AppendDecorators(
+			decor.OnComplete(done, "Finished!"),
+			note,
+		),
+	)
+	return progressBar{bar, note}
+}
 
+type progressBar struct {
+	*mpb.Bar
+	note *barNote
+}
+
+// finish completes the bar at done, noting how far short of want it fell.
+func (b progressBar) finish(done, want int) {
+	if done < want {
+		b.note.text.Store(fmt.Sprintf(" (%v short)", want-done))
+	}
+	b.SetTotal(int64(done), true)
+}
+
+// barNote is a decorator that shows text after a bar.
+type barNote struct {
+	decor.WC
+	text atomic.Value
+}
+
+func (n *barNote) Decor(*decor.Statistics) string {
+	s, _ := n.text.Load().(string)
+	return s
+}
+
+// departureCallsigns returns the callsigns of the flights from OpenSky
+// that departed airport and pass the filters.
+func (ft *fetcher) departureCallsigns(airport string, flights Sky) []CallsignOutput {
+	cfg := ft.cfg
 	output := []CallsignOutput{}
-	for _, ac := range r {
-		if len(ac.Callsign) < 3 {
+	for _, ac := range flights {
+		if prefix, ok := registrationPrefix(ac.Callsign, ft.gaPrefixes); ok && cfg.GA {
+			reg := strings.TrimSpace(ac.Callsign)
+			if ft.filters.Allowed(prefix, reg) && cfg.Routes.destinationAllowed(ac.EstArrivalAirport) &&
+				ft.distanceAllowed(airport, ac.EstArrivalAirport) {
+				output = append(output, CallsignOutput{Airline: prefix, ICAOCallsign: reg, GA: true,
+					Destination: ac.EstArrivalAirport, FirstSeen: int64(ac.FirstSeen)})
+			}
 			continue
 		}
-		if unicode.IsDigit(rune(ac.Callsign[0])) {
+		c, ok := parseCallsign(ac.Callsign)
+		if !ok {
+			log.Printf("%v: skipping unparseable callsign %q", airport, ac.Callsign)
 			continue
 		}
-		d := CallsignOutput{}
-		badCallsigns := []string{"CFR"} // we can add more to this later
-		if (unicode.IsDigit(rune(ac.Callsign[1])) && ac.Callsign[0] == 'N') || slices.Contains(badCallsigns, ac.Callsign[:3]) {
+		if !ft.filters.Allowed(c.Airline, c.String()) {
 			continue
-		} else {
-			d.Airline = ac.Callsign[:3]
 		}
-		if !unicode.IsDigit(rune(ac.Callsign[3])) {
+		// Skip flights OpenSky already knows are going elsewhere, before
+		// spending a FlightAware lookup on them.
+		if !cfg.Routes.destinationAllowed(ac.EstArrivalAirport) || !ft.distanceAllowed(airport, ac.EstArrivalAirport) {
 			continue
 		}
-		d.ICAOCallsign = ac.Callsign
-		d.ICAOCallsign = d.ICAOCallsign[:len(d.ICAOCallsign)-1]
-		output = append(output, d)
-		fetchBar.IncrBy(1)
-	}
-	if len(output) == 0 {
-		log.Fatalln("Couldn't gather any callsigns, exiting now.")
-	}
-	log.Println("Amount of Callsigns:", len(output))
-	fetchBar.SetTotal(int64(amount), true)
-	go flightAwareNonsenseDepartures(output, amount, departureBar)
-
-	// Use token in Authorization header for arrival request
-	url = fmt.Sprintf("https://opensky-network.org/api/flights/arrival?airport=%v&begin=%v&end=%v", airport, before, unixNow)
-	log.Printf("Arrival URL: %v\n", url)
-	req, err = http.NewRequest("GET", url, nil)
-	if err != nil {
-		panic(err)
+		ft.checkAirline(airport, c.Airline)
+		output = append(output, CallsignOutput{Airline: c.Airline, ICAOCallsign: c.String(),
+			Destination: ac.EstArrivalAirport, FirstSeen: int64(ac.FirstSeen)})
 	}
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-	resp, err = http.DefaultClient.Do(req)
-	if err != nil {
-		panic(err)
-	}
-	log.Printf("Arrival Response Status: %d\n", resp.StatusCode)
-	body, err = io.ReadAll(resp.Body)
-	if err != nil {
-		panic(err)
+	return output
+}
+
+// fetchAirport gathers the departures and arrivals for airport.
+func (ft *fetcher) fetchAirport(airport string, before, unixNow int64) {
+	cfg := ft.cfg
+	if n := cfg.departureAmount(); n > 0 {
+		lookupBar := ft.addBar(airport, "Look Up Callsigns", 0, false)
+		departureBar := ft.addBar(airport, "Fetch Departures", n, false)
+		go ft.flightAwareNonsenseDepartures(airport, ft.newFlightSource("departure", airport, before, unixNow),
+			lookupBar, departureBar)
 	}
-	log.Printf("Arrival Response Body: %s\n", string(body))
-	r = Sky{}
-	json.Unmarshal(body, &r)
 
+	amount := cfg.arrivalAmount()
+	if amount == 0 {
+		return
+	}
+	arrivalBar := ft.addBar(airport, "Fetch Arrivals", amount, true)
 	go func() {
 		defer wg.Done()
+		src := ft.newFlightSource("arrival", airport, before, unixNow)
 		arrivals := []Arrivals{}
-		for _, ac := range r {
-			if ac.EstDepartureAirport == "" || ac.EstArrivalAirport == "" { // Weed out pesky VFR traffic
-				continue
-			}
-			if len(ac.Callsign) < 3 {
-				continue
-			}
-			a := Arrivals{}
-			if unicode.IsDigit(rune(ac.Callsign[0])) {
-				continue
-			}
-			if unicode.IsDigit(rune(ac.Callsign[1])) && ac.Callsign[0] == 'N' {
-				a.Icao = "N"
-			} else {
-				a.Icao = ac.Callsign[:3]
+	Batch:
+		for len(arrivals) < amount {
+			flights, ok := src.next()
+			if !ok {
+				break
 			}
+			for _, ac := range flights {
+				if ac.EstDepartureAirport == "" || ac.EstArrivalAirport == "" { // Weed out pesky VFR traffic
+					continue
+				}
+				a := Arrivals{}
+				airline := false
+				if prefix, ok := registrationPrefix(ac.Callsign, ft.gaPrefixes); ok && cfg.GA {
+					a.Icao = prefix
+					if acType, ok := ft.registry[strings.TrimSpace(ac.Callsign)]; ok {
+						a.Fleet = gaFleet(ft.openscope, prefix, acType, "")
+					}
+				} else if c, ok := parseCallsign(ac.Callsign); ok {
+					a.Icao = c.Airline
+					a.Name, a.Callsign = ft.airlineInfo(c.Airline)
+					airline = true
+				} else if prefix == "N" {
+					a.Icao = "N"
+				} else {
+					continue
+				}
+				if !ft.filters.Allowed(a.Icao, strings.TrimSpace(ac.Callsign)) {
+					continue
+				}
+				if airline {
+					ft.checkAirline(airport, a.Icao)
+				}
 
-			a.Airport = ac.EstDepartureAirport
-			arrivalBar.IncrBy(1)
-			arrivals = append(arrivals, a)
-			if len(arrivals) == amount {
-				break
+				a.Airport = ac.EstDepartureAirport
+				arrivalBar.IncrBy(1)
+				arrivals = append(arrivals, a)
+				if len(arrivals) == amount {
+					break Batch
+				}
 			}
 		}
-		e, _ := os.Create("arrivals.json")
-		f, err := json.MarshalIndent(arrivals, "", "    ")
-		if err != nil {
+		arrivalBar.finish(len(arrivals), amount)
+		if err := ft.writeArrivals(airport, arrivals); err != nil {
 			panic(err)
 		}
-		e.Write(f)
-		log.Println("Arrivals done")
+		log.Printf("%v: arrivals done", airport)
 	}()
-	p.Wait()
 }
 
 func cleanUpString(text string) (string, error) {
@@ -559,129 +945,132 @@ type Response struct {
 }
 
 type FlightAwareResponse struct {
-	Flights []struct {
-		Origin struct {
-			Tz                    string    `json:"TZ"`
-			IsValidAirportCode    bool      `json:"isValidAirportCode"`
-			IsCustomGlobalAirport bool      `json:"isCustomGlobalAirport"`
-			AltIdent              any       `json:"altIdent"`
-			Iata                  string    `json:"iata"`
-			FriendlyName          string    `json:"friendlyName"`
-			FriendlyLocation      string    `json:"friendlyLocation"`
-			Coord                 []float64 `json:"coord"`
-			IsLatLon              bool      `json:"isLatLon"`
-			Icao                  string    `json:"icao"`
-			Gate                  any       `json:"gate"`
-			Terminal              any       `json:"terminal"`
-			Delays                any       `json:"delays"`
-		} `json:"origin"`
-		Destination struct {
-			Tz                    string    `json:"TZ"`
-			IsValidAirportCode    bool      `json:"isValidAirportCode"`
-			IsCustomGlobalAirport bool      `json:"isCustomGlobalAirport"`
-			AltIdent              any       `json:"altIdent"`
-			Iata                  string    `json:"iata"`
-			FriendlyName          string    `json:"friendlyName"`
-			FriendlyLocation      string    `json:"friendlyLocation"`
-			Coord                 []float64 `json:"coord"`
-			IsLatLon              bool      `json:"isLatLon"`
-			Icao                  string    `json:"icao"`
-			Gate                  any       `json:"gate"`
-			Terminal              any       `json:"terminal"`
-			Delays                any       `json:"delays"`
-		} `json:"destination"`
-		AircraftType         string `json:"aircraftType"`
-		AircraftTypeFriendly string `json:"aircraftTypeFriendly"`
-		FlightID             string `json:"flightId"`
-		TakeoffTimes         struct {
-			Scheduled int `json:"scheduled"`
-			Estimated int `json:"estimated"`
-			Actual    any `json:"actual"`
-		} `json:"takeoffTimes"`
-		LandingTimes struct {
-			Scheduled int `json:"scheduled"`
-			Estimated int `json:"estimated"`
-			Actual    any `json:"actual"`
-		} `json:"landingTimes"`
-		GateDepartureTimes struct {
-			Scheduled int `json:"scheduled"`
-			Estimated any `json:"estimated"`
-			Actual    any `json:"actual"`
-		} `json:"gateDepartureTimes"`
-		GateArrivalTimes struct {
-			Scheduled int `json:"scheduled"`
-			Estimated any `json:"estimated"`
-			Actual    any `json:"actual"`
-		} `json:"gateArrivalTimes"`
-		Ga                   bool   `json:"ga"`
-		FlightStatus         string `json:"flightStatus"`
-		FpasAvailable        bool   `json:"fpasAvailable"`
-		CanEdit              bool   `json:"canEdit"`
-		Cancelled            bool   `json:"cancelled"`
-		ResultUnknown        bool   `json:"resultUnknown"`
-		Diverted             bool   `json:"diverted"`
-		Adhoc                bool   `json:"adhoc"`
-		FruOverride          bool   `json:"fruOverride"`
-		Timestamp            any    `json:"timestamp"`
-		RoundedTimestamp     any    `json:"roundedTimestamp"`
-		PermaLink            string `json:"permaLink"`
-		TaxiIn               any    `json:"taxiIn"`
-		TaxiOut              any    `json:"taxiOut"`
-		GlobalIdent          bool   `json:"globalIdent"`
-		GlobalFlightFeatures bool   `json:"globalFlightFeatures"`
-		GlobalVisualizer     bool   `json:"globalVisualizer"`
-		FlightPlan           struct {
-			Speed           int    `json:"speed"`
-			Altitude        any    `json:"altitude"`
-			Route           string `json:"route"`
-			DirectDistance  int    `json:"directDistance"`
-			PlannedDistance any    `json:"plannedDistance"`
-			Departure       int    `json:"departure"`
-			Ete             int    `json:"ete"`
-			FuelBurn        struct {
-				Gallons int `json:"gallons"`
-				Pounds  int `json:"pounds"`
-			} `json:"fuelBurn"`
-		} `json:"flightPlan"`
-		Links struct {
-			Operated           string `json:"operated"`
-			Registration       string `json:"registration"`
-			Permanent          string `json:"permanent"`
-			TrackLog           string `json:"trackLog"`
-			FlightHistory      string `json:"flightHistory"`
-			BuyFlightHistory   string `json:"buyFlightHistory"`
-			ReportInaccuracies string `json:"reportInaccuracies"`
-			Facebook           string `json:"facebook"`
-			Twitter            string `json:"twitter"`
-		} `json:"links"`
-		Aircraft struct {
-			Type          string `json:"type"`
-			Lifeguard     bool   `json:"lifeguard"`
-			Heavy         bool   `json:"heavy"`
-			Tail          any    `json:"tail"`
-			Owner         any    `json:"owner"`
-			OwnerLocation any    `json:"ownerLocation"`
-			OwnerType     any    `json:"owner_type"`
-			CanMessage    bool   `json:"canMessage"`
-			FriendlyType  string `json:"friendlyType"`
-			TypeDetails   struct {
-				Manufacturer string `json:"manufacturer"`
-				Model        string `json:"model"`
-				Type         string `json:"type"`
-				EngCount     string `json:"engCount"`
-				EngType      string `json:"engType"`
-			} `json:"typeDetails"`
-		} `json:"aircraft"`
-		DisplayIdent       string `json:"displayIdent"`
-		EncryptedFlightID  string `json:"encryptedFlightId"`
-		PredictedAvailable bool   `json:"predictedAvailable"`
-		PredictedTimes     struct {
-			Out any `json:"out"`
-			Off any `json:"off"`
-			On  any `json:"on"`
-			In  any `json:"in"`
-		} `json:"predictedTimes"`
-	} `json:"flights"`
+	Flights []FlightAwareFlight `json:"flights"`
+}
+
+// FlightAwareFlight is one leg of a flight number in a FlightAware response.
+type FlightAwareFlight struct {
+	Origin struct {
+		Tz                    string    `json:"TZ"`
+		IsValidAirportCode    bool      `json:"isValidAirportCode"`
+		IsCustomGlobalAirport bool      `json:"isCustomGlobalAirport"`
+		AltIdent              any       `json:"altIdent"`
+		Iata                  string    `json:"iata"`
+		FriendlyName          string    `json:"friendlyName"`
+		FriendlyLocation      string    `json:"friendlyLocation"`
+		Coord                 []float64 `json:"coord"`
+		IsLatLon              bool      `json:"isLatLon"`
+		Icao                  string    `json:"icao"`
+		Gate                  any       `json:"gate"`
+		Terminal              any       `json:"terminal"`
+		Delays                any       `json:"delays"`
+	} `json:"origin"`
+	Destination struct {
+		Tz                    string    `json:"TZ"`
+		IsValidAirportCode    bool      `json:"isValidAirportCode"`
+		IsCustomGlobalAirport bool      `json:"isCustomGlobalAirport"`
+		AltIdent              any       `json:"altIdent"`
+		Iata                  string    `json:"iata"`
+		FriendlyName          string    `json:"friendlyName"`
+		FriendlyLocation      string    `json:"friendlyLocation"`
+		Coord                 []float64 `json:"coord"`
+		IsLatLon              bool      `json:"isLatLon"`
+		Icao                  string    `json:"icao"`
+		Gate                  any       `json:"gate"`
+		Terminal              any       `json:"terminal"`
+		Delays                any       `json:"delays"`
+	} `json:"destination"`
+	AircraftType         string `json:"aircraftType"`
+	AircraftTypeFriendly string `json:"aircraftTypeFriendly"`
+	FlightID             string `json:"flightId"`
+	TakeoffTimes         struct {
+		Scheduled int `json:"scheduled"`
+		Estimated int `json:"estimated"`
+		Actual    any `json:"actual"`
+	} `json:"takeoffTimes"`
+	LandingTimes struct {
+		Scheduled int `json:"scheduled"`
+		Estimated int `json:"estimated"`
+		Actual    any `json:"actual"`
+	} `json:"landingTimes"`
+	GateDepartureTimes struct {
+		Scheduled int `json:"scheduled"`
+		Estimated any `json:"estimated"`
+		Actual    any `json:"actual"`
+	} `json:"gateDepartureTimes"`
+	GateArrivalTimes struct {
+		Scheduled int `json:"scheduled"`
+		Estimated any `json:"estimated"`
+		Actual    any `json:"actual"`
+	} `json:"gateArrivalTimes"`
+	Ga                   bool   `json:"ga"`
+	FlightStatus         string `json:"flightStatus"`
+	FpasAvailable        bool   `json:"fpasAvailable"`
+	CanEdit              bool   `json:"canEdit"`
+	Cancelled            bool   `json:"cancelled"`
+	ResultUnknown        bool   `json:"resultUnknown"`
+	Diverted             bool   `json:"diverted"`
+	Adhoc                bool   `json:"adhoc"`
+	FruOverride          bool   `json:"fruOverride"`
+	Timestamp            any    `json:"timestamp"`
+	RoundedTimestamp     any    `json:"roundedTimestamp"`
+	PermaLink            string `json:"permaLink"`
+	TaxiIn               any    `json:"taxiIn"`
+	TaxiOut              any    `json:"taxiOut"`
+	GlobalIdent          bool   `json:"globalIdent"`
+	GlobalFlightFeatures bool   `json:"globalFlightFeatures"`
+	GlobalVisualizer     bool   `json:"globalVisualizer"`
+	FlightPlan           struct {
+		Speed           int    `json:"speed"`
+		Altitude        any    `json:"altitude"`
+		Route           string `json:"route"`
+		DirectDistance  int    `json:"directDistance"`
+		PlannedDistance any    `json:"plannedDistance"`
+		Departure       int    `json:"departure"`
+		Ete             int    `json:"ete"`
+		FuelBurn        struct {
+			Gallons int `json:"gallons"`
+			Pounds  int `json:"pounds"`
+		} `json:"fuelBurn"`
+	} `json:"flightPlan"`
+	Links struct {
+		Operated           string `json:"operated"`
+		Registration       string `json:"registration"`
+		Permanent          string `json:"permanent"`
+		TrackLog           string `json:"trackLog"`
+		FlightHistory      string `json:"flightHistory"`
+		BuyFlightHistory   string `json:"buyFlightHistory"`
+		ReportInaccuracies string `json:"reportInaccuracies"`
+		Facebook           string `json:"facebook"`
+		Twitter            string `json:"twitter"`
+	} `json:"links"`
+	Aircraft struct {
+		Type          string `json:"type"`
+		Lifeguard     bool   `json:"lifeguard"`
+		Heavy         bool   `json:"heavy"`
+		Tail          any    `json:"tail"`
+		Owner         any    `json:"owner"`
+		OwnerLocation any    `json:"ownerLocation"`
+		OwnerType     any    `json:"owner_type"`
+		CanMessage    bool   `json:"canMessage"`
+		FriendlyType  string `json:"friendlyType"`
+		TypeDetails   struct {
+			Manufacturer string `json:"manufacturer"`
+			Model        string `json:"model"`
+			Type         string `json:"type"`
+			EngCount     string `json:"engCount"`
+			EngType      string `json:"engType"`
+		} `json:"typeDetails"`
+	} `json:"aircraft"`
+	DisplayIdent       string `json:"displayIdent"`
+	EncryptedFlightID  string `json:"encryptedFlightId"`
+	PredictedAvailable bool   `json:"predictedAvailable"`
+	PredictedTimes     struct {
+		Out any `json:"out"`
+		Off any `json:"off"`
+		On  any `json:"on"`
+		In  any `json:"in"`
+	} `json:"predictedTimes"`
 }
 
 type ScratchpadRules struct {
@@ -692,7 +1081,9 @@ type ScratchpadRules struct {
 	} `json:"rules,omitempty"`
 }
 
-type Sky []struct {
+type Sky []SkyFlight
+
+type SkyFlight struct {
 	Icao24                           string `json:"icao24"`
 	FirstSeen                        int    `json:"firstSeen"`
 	EstDepartureAirport              string `json:"estDepartureAirport"`
@@ -708,6 +1099,9 @@ type Sky []struct {
 }
 
 type Arrivals struct {
-	Airport string `json:"airport"`
-	Icao    string `json:"icao"`
+	Airport  string `json:"airport"`
+	Icao     string `json:"icao"`
+	Fleet    string `json:"fleet,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Callsign string `json:"callsign,omitempty"`
 }
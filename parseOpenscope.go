@@ -1,19 +1,31 @@
-package main 
+package main
 
 import (
-	"io/fs"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"path/filepath"
+	"io/fs"
 	"os"
-	"encoding/json"
-	"strings"
+	"path/filepath"
 	"runtime"
+	"strings"
 
 	"github.com/klauspost/compress/zstd"
 )
 
 var decoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
-var resourcesFS fs.StatFS = getResourcesFS()
+
+// resourcesFS is where resource files are read from. It's set up by
+// initResources once the configuration is known.
+var resourcesFS fs.StatFS
+
+// resourcesDir is the folder resourcesFS refers to, for messages.
+var resourcesDir string
+
+// viceResourcesFS is a vice installation's resources folder, if one was
+// given with -vice-resources.
+var viceResourcesFS fs.StatFS
+var viceResourcesDir string
 
 type FleetAircraft struct {
 	ICAO  string
@@ -31,31 +43,75 @@ type Airlines struct {
 	Fleets     map[string][]FleetAircraft
 }
 
-func LoadResource(path string) []byte {
-	b, err := os.ReadFile("resources/openscope-airlines.json")
-	if err != nil {
-		panic(err)
+// LoadResource reads the file at path in the resources folder.
+func LoadResource(path string) ([]byte, error) {
+	return loadResourceFS(resourcesFS, path)
+}
+
+// loadJSONResource decodes the JSON resource at path. If optional is set,
+// a missing resource gives the zero value rather than an error.
+func loadJSONResource[T any](path string, optional bool) (T, error) {
+	var v T
+	b, err := LoadResource(path)
+	if errors.Is(err, fs.ErrNotExist) && optional {
+		return v, nil
+	} else if err != nil {
+		return v, err
 	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return v, fmt.Errorf("%v: %w", resourceName(path), err)
+	}
+	return v, nil
+}
 
-	return b
+func loadResourceFS(fsys fs.FS, path string) ([]byte, error) {
+	b, err := fs.ReadFile(fsys, path)
+	if errors.Is(err, fs.ErrNotExist) {
+		zb, zerr := fs.ReadFile(fsys, path+".zst")
+		if zerr != nil {
+			return nil, err
+		}
+		return decompressZstd(zb)
+	}
+	return b, err
 }
 
-func decompressZstd(s string) string {
-	b, err := decoder.DecodeAll([]byte(s), nil)
+func decompressZstd(b []byte) ([]byte, error) {
+	b, err := decoder.DecodeAll(b, nil)
 	if err != nil {
-		fmt.Printf("Error decompressing buffer")
+		return nil, fmt.Errorf("error decompressing buffer: %w", err)
 	}
-	return string(b)
+	return b, nil
 }
 
-func parseAirlines() (map[string]Airlines, map[string]string) {
-	openscopeAirlines := LoadResource("openscope-airlines.json")
+// resourceName returns a name for the resource at path that's useful in
+// messages.
+func resourceName(path string) string {
+	return filepath.Join(resourcesDir, path)
+}
+
+// loadAirlineDatabase returns the contents of openscope-airlines.json and
+// a name for it, preferring vice's copy if there is one.
+func loadAirlineDatabase() ([]byte, string, error) {
+	if viceResourcesFS != nil {
+		b, err := loadResourceFS(viceResourcesFS, "openscope-airlines.json")
+		return b, filepath.Join(viceResourcesDir, "openscope-airlines.json"), err
+	}
+	b, err := LoadResource("openscope-airlines.json")
+	return b, resourceName("openscope-airlines.json"), err
+}
+
+func parseAirlines() (map[string]Airlines, map[string]string, error) {
+	openscopeAirlines, name, err := loadAirlineDatabase()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%v: %w", name, err)
+	}
 
 	var alStruct struct {
 		Airlines []Airlines `json:"airlines"`
 	}
 	if err := json.Unmarshal([]byte(openscopeAirlines), &alStruct); err != nil {
-		fmt.Printf("error in JSON unmarshal of openscope-airlines: %v", err)
+		return nil, nil, fmt.Errorf("error in JSON unmarshal of openscope-airlines: %w", err)
 	}
 
 	airlines := make(map[string]Airlines)
@@ -77,13 +133,50 @@ func parseAirlines() (map[string]Airlines, map[string]string) {
 		airlines[strings.ToUpper(al.ICAO)] = fixedAirline
 		callsigns[strings.ToUpper(al.ICAO)] = al.Callsign.Name
 	}
-	return airlines, callsigns
+	return airlines, callsigns, nil
+}
+
+// initResources sets up resourcesFS and, if viceDir is given,
+// viceResourcesFS.
+func initResources(dir, viceDir string) error {
+	if viceDir != "" {
+		fsys, vdir, err := findViceResources(viceDir)
+		if err != nil {
+			return err
+		}
+		viceResourcesFS, viceResourcesDir = fsys, vdir
+	}
+
+	if dir != "" {
+		fsys, err := dirFS(dir)
+		if err != nil {
+			return err
+		}
+		resourcesFS, resourcesDir = fsys, dir
+		return nil
+	}
+
+	fsys, dir, err := getResourcesFS(viceDir == "")
+	if err != nil {
+		return err
+	}
+	resourcesFS, resourcesDir = fsys, dir
+	return nil
 }
 
-func getResourcesFS() fs.StatFS {
+func dirFS(dir string) (fs.StatFS, error) {
+	fsys, ok := os.DirFS(dir).(fs.StatFS)
+	if !ok {
+		return nil, errors.New("FS from DirFS is not a StatFS?")
+	}
+	return fsys, nil
+}
+
+// getResourcesFS finds the resources folder.
+func getResourcesFS(requireAirlines bool) (fs.StatFS, string, error) {
 	path, err := os.Executable()
 	if err != nil {
-		panic(err)
+		return nil, "", err
 	}
 
 	dir := filepath.Dir(path)
@@ -93,41 +186,67 @@ func getResourcesFS() fs.StatFS {
 		dir = filepath.Join(dir, "resources")
 	}
 
-	fsys, ok := os.DirFS(dir).(fs.StatFS)
-	if !ok {
-		panic("FS from DirFS is not a StatFS?")
+	fsys, err := dirFS(dir)
+	if err != nil {
+		return nil, "", err
 	}
 
-	check := func(fs fs.StatFS) bool {
-		_, errv := fsys.Stat("videomaps")
-		_, errs := fsys.Stat("scenarios")
-		return errv == nil && errs == nil
+	check := func(fsys fs.StatFS) bool {
+		if !requireAirlines {
+			_, err := fsys.Stat(".")
+			return err == nil
+		}
+		return hasAirlineDatabase(fsys)
 	}
 
-	return fsys
-
 	if check(fsys) {
-		return fsys
+		return fsys, dir, nil
 	}
 
-	// Try CWD (this is useful for development and debugging but shouldn't
-	// be needed for release builds.
+	// Try CWD (this is useful for development and debugging, and for
+	// running with "go run", where the executable is in a temporary
+	// folder.)
 
 	wd, err := os.Getwd()
 	if err != nil {
-		panic(err)
+		return nil, "", err
 	}
 
 	dir = filepath.Join(wd, "resources")
 
-	fsys, ok = os.DirFS(dir).(fs.StatFS)
-	if !ok {
-		panic("FS from DirFS is not a StatFS?")
+	fsys, err = dirFS(dir)
+	if err != nil {
+		return nil, "", err
 	}
 
-	if check(fsys) {
-		return fsys
+	if check(fsys) || !requireAirlines {
+		return fsys, dir, nil
 	}
-	panic("unable to find videomaps in CWD")
+	return nil, "", errors.New("unable to find openscope-airlines.json in a resources folder next to the executable or in the current directory")
 }
 
+func hasAirlineDatabase(fsys fs.StatFS) bool {
+	_, err := fsys.Stat("openscope-airlines.json")
+	_, errz := fsys.Stat("openscope-airlines.json.zst")
+	return err == nil || errz == nil
+}
+
+// findViceResources finds the resources folder of the vice installation at
+// path, which may be the resources folder itself, the folder vice is
+// installed in, or the Vice.app bundle on macOS.
+func findViceResources(path string) (fs.StatFS, string, error) {
+	for _, dir := range []string{
+		path,
+		filepath.Join(path, "resources"),
+		filepath.Join(path, "Contents", "Resources"),
+	} {
+		fsys, err := dirFS(dir)
+		if err != nil {
+			return nil, "", err
+		}
+		if hasAirlineDatabase(fsys) {
+			return fsys, dir, nil
+		}
+	}
+	return nil, "", fmt.Errorf("%v: unable to find vice's openscope-airlines.json", path)
+}
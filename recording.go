@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// recording saves the OpenSky and FlightAware responses a run used to a
+// folder, or replays a run from one without going to the network, so that
+// the same inputs always give byte-identical output.
+type recording struct {
+	dir    string
+	replay bool
+}
+
+// recordedRun is what's saved about a recorded run besides the responses.
+type recordedRun struct {
+	Window Window `json:"window"`
+	Seed   int64  `json:"seed"`
+}
+
+func (r *recording) path(kind, name string) string {
+	return filepath.Join(r.dir, kind, name+".json")
+}
+
+// load reads the recorded response of the given kind and name into v.
+func (r *recording) load(kind, name string, v any) error {
+	b, err := os.ReadFile(r.path(kind, name))
+	if err != nil {
+		return fmt.Errorf("no recorded response: %w", err)
+	}
+	return json.Unmarshal(b, v)
+}
+
+// save records v as the response of the given kind and name.
+func (r *recording) save(kind, name string, v any) error {
+	return writeOutput(r.path(kind, name), v)
+}
+
+// pin saves the window and seed of cfg with the recording. The window must
+// have been pinned with Config.pinWindow, so that it's the one fetched.
+func (r *recording) pin(cfg Config) error {
+	run := recordedRun{Window: cfg.Window, Seed: cfg.Seed}
+	return writeOutput(filepath.Join(r.dir, "run.json"), run)
+}
+
+// restore sets cfg's window to the recorded one, so that the replayed run
+// asks for the same responses, and its seed to the recorded one unless a
+// seed was given.
+func (r *recording) restore(cfg *Config) error {
+	path := filepath.Join(r.dir, "run.json")
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var run recordedRun
+	if err := json.Unmarshal(b, &run); err != nil {
+		return fmt.Errorf("%v: %w", path, err)
+	}
+	cfg.Window = run.Window
+	if cfg.Seed == 0 {
+		cfg.Seed = run.Seed
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Report collects the things worth knowing about a run that don't belong in
+// the vice output.
+type Report struct {
+	mu sync.Mutex
+	// Seed is the random seed the run used, to repeat it with -seed.
+	Seed     int64                     `json:"seed"`
+	Airports map[string]*AirportReport `json:"airports"`
+}
+
+type AirportReport struct {
+	// Departures says how many departures were asked for and produced,
+	// and how many FlightAware lookups that took.
+	// It's nil if departures weren't fetched.
+	Departures *DepartureCount `json:"departures,omitempty"`
+	// MissingAirlines counts the flights seen for each airline that isn't
+	// in openscope-airlines.json; vice can't spawn those.
+	MissingAirlines map[string]int `json:"missing_airlines,omitempty"`
+	// AmbiguousFleets are departures whose type was in several fleets
+	// that were equally plausible, so the fleet was picked by name.
+	AmbiguousFleets []FleetChoice `json:"ambiguous_fleets,omitempty"`
+	// Substitutions are departures whose type wasn't in any of the
+	// airline's fleets, placed using an equivalent type instead.
+	Substitutions []TypeSubstitution `json:"substitutions,omitempty"`
+	// AltitudeProblems are departures with nonsense altitudes or ones
+	// that are wrong for their direction of flight, when those are
+	// checked.
+	AltitudeProblems []AltitudeProblem `json:"altitude_problems,omitempty"`
+	// Mismatches are departures whose flight plan disagrees with what
+	// OpenSky saw of them.
+	Mismatches []Mismatch `json:"mismatches,omitempty"`
+	// Categories counts the departures produced in each wake category and
+	// engine class.
+	Categories *CategoryCounts `json:"categories,omitempty"`
+}
+
+// CategoryCounts are departure counts by legacy wake category, RECAT
+// category and engine class.
+type CategoryCounts struct {
+	Wake    map[string]int `json:"wake"`
+	RECAT   map[string]int `json:"recat"`
+	Engines map[string]int `json:"engines"`
+}
+
+type DepartureCount struct {
+	Wanted   int `json:"wanted"`
+	Produced int `json:"produced"`
+	Lookups  int `json:"lookups"`
+}
+
+func newReport() *Report {
+	return &Report{Airports: make(map[string]*AirportReport)}
+}
+
+// update calls fn with the report for airport while holding the report's
+// lock, so that it's safe to use from the departure and arrival goroutines.
+func (r *Report) update(airport string, fn func(ar *AirportReport)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ar, ok := r.Airports[airport]
+	if !ok {
+		ar = &AirportReport{MissingAirlines: make(map[string]int)}
+		r.Airports[airport] = ar
+	}
+	fn(ar)
+}
+
+func (r *Report) missingAirline(airport, icao string) {
+	r.update(airport, func(ar *AirportReport) { ar.MissingAirlines[icao]++ })
+}
+
+func (r *Report) departures(airport string, wanted, produced, lookups int) {
+	r.update(airport, func(ar *AirportReport) {
+		ar.Departures = &DepartureCount{Wanted: wanted, Produced: produced, Lookups: lookups}
+	})
+}
+
+func (r *Report) category(airport string, d DepartureDetails) {
+	r.update(airport, func(ar *AirportReport) {
+		if ar.Categories == nil {
+			ar.Categories = &CategoryCounts{
+				Wake:    make(map[string]int),
+				RECAT:   make(map[string]int),
+				Engines: make(map[string]int),
+			}
+		}
+		count := func(m map[string]int, c string) {
+			if c == "" {
+				c = "unknown"
+			}
+			m[c]++
+		}
+		count(ar.Categories.Wake, d.Wake)
+		count(ar.Categories.RECAT, d.RECAT)
+		count(ar.Categories.Engines, d.Engine)
+	})
+}
+
+func (r *Report) mismatch(airport string, m Mismatch) {
+	r.update(airport, func(ar *AirportReport) { ar.Mismatches = append(ar.Mismatches, m) })
+}
+
+func (r *Report) ambiguousFleet(airport string, c FleetChoice) {
+	r.update(airport, func(ar *AirportReport) { ar.AmbiguousFleets = append(ar.AmbiguousFleets, c) })
+}
+
+func (r *Report) substitution(airport string, s TypeSubstitution) {
+	r.update(airport, func(ar *AirportReport) { ar.Substitutions = append(ar.Substitutions, s) })
+}
+
+func (r *Report) altitudeProblem(airport string, p AltitudeProblem) {
+	r.update(airport, func(ar *AirportReport) { ar.AltitudeProblems = append(ar.AltitudeProblems, p) })
+}
+
+// summary returns a human-readable summary of the report.
+func (r *Report) summary() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sb strings.Builder
+	for _, airport := range sortedKeys(r.Airports) {
+		ar := r.Airports[airport]
+		if dc := ar.Departures; dc != nil && dc.Produced < dc.Wanted {
+			fmt.Fprintf(&sb, "%v: only %v of %v departures could be generated after %v lookups\n",
+				airport, dc.Produced, dc.Wanted, dc.Lookups)
+		}
+		if c := ar.Categories; c != nil {
+			fmt.Fprintf(&sb, "%v: departures by wake category %v; RECAT %v; engines %v\n",
+				airport, counts(c.Wake), counts(c.RECAT), counts(c.Engines))
+		}
+		if len(ar.MissingAirlines) > 0 {
+			var missing []string
+			for _, icao := range sortedKeys(ar.MissingAirlines) {
+				missing = append(missing, fmt.Sprintf("%v (%v)", icao, ar.MissingAirlines[icao]))
+			}
+			fmt.Fprintf(&sb, "%v: %v airline(s) not in openscope-airlines.json, which vice can't spawn: %v\n",
+				airport, len(missing), strings.Join(missing, ", "))
+		}
+		if len(ar.Substitutions) > 0 {
+			fmt.Fprintf(&sb, "%v: %v departure(s) were placed using an equivalent aircraft type; see the report\n",
+				airport, len(ar.Substitutions))
+		}
+		if len(ar.AltitudeProblems) > 0 {
+			fmt.Fprintf(&sb, "%v: %v departure(s) had a bad cruise altitude; see the report\n",
+				airport, len(ar.AltitudeProblems))
+		}
+		if len(ar.Mismatches) > 0 {
+			discarded := 0
+			for _, m := range ar.Mismatches {
+				if m.Discarded {
+					discarded++
+				}
+			}
+			fmt.Fprintf(&sb, "%v: %v flight plan(s) didn't match what OpenSky saw (%v discarded); see the report\n",
+				airport, len(ar.Mismatches), discarded)
+		}
+		if len(ar.AmbiguousFleets) > 0 {
+			fmt.Fprintf(&sb, "%v: %v departure(s) could have gone in more than one fleet; see the report\n",
+				airport, len(ar.AmbiguousFleets))
+		}
+	}
+	return sb.String()
+}
+
+// counts formats m as e.g. "H 2, L 10".
+func counts(m map[string]int) string {
+	var s []string
+	for _, k := range sortedKeys(m) {
+		s = append(s, fmt.Sprintf("%v %v", k, m[k]))
+	}
+	return strings.Join(s, ", ")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
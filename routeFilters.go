@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// RouteFilters limit a run to departures through certain exits or to certain
+// destinations.
+type RouteFilters struct {
+	Exits               []string `json:"exits,omitempty"`
+	Destinations        []string `json:"destinations,omitempty"`
+	ExcludeDestinations []string `json:"exclude_destinations,omitempty"`
+}
+
+// splitList splits a comma-separated flag value into uppercased items,
+// dropping blanks.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.ToUpper(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// check returns an error for the first destination pattern that's
+// malformed.
+func (r RouteFilters) check() error {
+	for _, p := range append(slices.Clone(r.Destinations), r.ExcludeDestinations...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("destination pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// destinationAllowed reports whether departures to icao should be kept.
+func (r RouteFilters) destinationAllowed(icao string) bool {
+	icao = strings.ToUpper(strings.TrimSpace(icao))
+	if icao == "" {
+		return true
+	}
+	match := func(patterns []string) bool {
+		return slices.ContainsFunc(patterns, func(p string) bool {
+			ok, _ := path.Match(strings.ToUpper(p), icao)
+			return ok
+		})
+	}
+	if len(r.Destinations) > 0 && !match(r.Destinations) {
+		return false
+	}
+	return !match(r.ExcludeDestinations)
+}
+
+// exitAllowed reports whether departures through exit should be kept.
+func (r RouteFilters) exitAllowed(exit string) bool {
+	return len(r.Exits) == 0 || slices.ContainsFunc(r.Exits, func(e string) bool {
+		return strings.EqualFold(e, exit)
+	})
+}
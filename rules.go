@@ -0,0 +1,49 @@
+package main
+
+import (
+	"slices"
+)
+
+// loadScratchpadRules reads the scratchpad rules at path in the resources
+// folder. A missing file isn't an error; it just means there are no rules.
+func loadScratchpadRules(path string) (ScratchpadRules, error) {
+	return loadJSONResource[ScratchpadRules](path, true)
+}
+
+// loadExitExceptions reads the exit exceptions at path in the resources
+// folder.
+func loadExitExceptions(path string) (exitExeptions, error) {
+	return loadJSONResource[exitExeptions](path, true)
+}
+
+// applyExitExceptions replaces exit with one of the actual exits from a
+// matching exception if that fix appears in the route.
+func applyExitExceptions(exceptions exitExeptions, exit string, waypoints []string) string {
+	for _, exeption := range exceptions {
+		if exeption.FoundExit != exit {
+			continue
+		}
+		for _, fix := range waypoints {
+			if slices.Contains(exeption.ActualExit, fix) {
+				exit = fix
+			}
+		}
+	}
+	return exit
+}
+
+// applyScratchpadRules fills in the scratchpads for d from the rules
+// matching its exit.
+func applyScratchpadRules(rules ScratchpadRules, d *Departure) {
+	for _, rule := range rules.Rules {
+		if rule.Exit != d.Exit {
+			continue
+		}
+		if rule.Scratchpad != "" {
+			d.Scratchpad = rule.Scratchpad
+		}
+		if rule.SecondaryScratchpad != "" {
+			d.SecondaryScratchpad = rule.SecondaryScratchpad
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"slices"
+)
+
+// Sampling strategies, for Config.Sampling.
+const (
+	// samplingFirst takes callsigns in the order OpenSky returned them.
+	samplingFirst = "first"
+	// samplingRandom shuffles them.
+	samplingRandom = "random"
+	// samplingAirline takes one from each airline in turn, so that every
+	// airline is equally represented.
+	samplingAirline = "airline"
+	// samplingDestination takes one for each destination in turn, which
+	// also spreads departures over the exits.
+	samplingDestination = "destination"
+	// samplingProportional keeps each airline's share of the departures
+	// the same as its share of the airport's traffic.
+	samplingProportional = "proportional"
+)
+
+// validSampling returns an error if strategy isn't a sampling strategy.
+func validSampling(strategy string) error {
+	switch strategy {
+	case "", samplingFirst, samplingRandom, samplingAirline, samplingDestination, samplingProportional:
+		return nil
+	default:
+		return fmt.Errorf("%q is not a valid sampling strategy; use %q, %q, %q, %q or %q", strategy,
+			samplingFirst, samplingRandom, samplingAirline, samplingDestination, samplingProportional)
+	}
+}
+
+// airportRand returns the random number generator for airport's sampling.
+func airportRand(seed int64, airport string) *rand.Rand {
+	h := fnv.New64a()
+	h.Write([]byte(airport))
+	return rand.New(rand.NewSource(seed ^ int64(h.Sum64())))
+}
+
+// sampleCallsigns returns callsigns in the order strategy says they should
+// be looked up in.
+func sampleCallsigns(callsigns []CallsignOutput, strategy string, rng *rand.Rand) []CallsignOutput {
+	switch strategy {
+	case samplingRandom:
+		cs := slices.Clone(callsigns)
+		rng.Shuffle(len(cs), func(i, j int) { cs[i], cs[j] = cs[j], cs[i] })
+		return cs
+	case samplingAirline:
+		return roundRobin(groupCallsigns(callsigns, rng, func(c CallsignOutput) string { return c.Airline }), rng)
+	case samplingDestination:
+		return roundRobin(groupCallsigns(callsigns, rng, func(c CallsignOutput) string { return c.Destination }), rng)
+	case samplingProportional:
+		return proportional(groupCallsigns(callsigns, rng, func(c CallsignOutput) string { return c.Airline }), len(callsigns))
+	default:
+		return callsigns
+	}
+}
+
+// groupCallsigns splits callsigns up by key, shuffling each group. The
+// groups are sorted by key.
+func groupCallsigns(callsigns []CallsignOutput, rng *rand.Rand, key func(CallsignOutput) string) [][]CallsignOutput {
+	groups := make(map[string][]CallsignOutput)
+	for _, c := range callsigns {
+		groups[key(c)] = append(groups[key(c)], c)
+	}
+	var sorted [][]CallsignOutput
+	for _, k := range sortedKeys(groups) {
+		g := groups[k]
+		rng.Shuffle(len(g), func(i, j int) { g[i], g[j] = g[j], g[i] })
+		sorted = append(sorted, g)
+	}
+	return sorted
+}
+
+// roundRobin takes one callsign from each group in turn, visiting the
+// groups in a random order.
+func roundRobin(groups [][]CallsignOutput, rng *rand.Rand) []CallsignOutput {
+	rng.Shuffle(len(groups), func(i, j int) { groups[i], groups[j] = groups[j], groups[i] })
+	var out []CallsignOutput
+	for i := 0; len(groups) > 0; i++ {
+		var rest [][]CallsignOutput
+		for _, g := range groups {
+			if i < len(g) {
+				out = append(out, g[i])
+				rest = append(rest, g)
+			}
+		}
+		groups = rest
+	}
+	return out
+}
+
+// proportional interleaves the groups so that every prefix of the result has
+// each group in about the proportion it has overall.
+func proportional(groups [][]CallsignOutput, total int) []CallsignOutput {
+	taken := make([]int, len(groups))
+	var out []CallsignOutput
+	for n := 1; n <= total; n++ {
+		best, bestDeficit := -1, 0.0
+		for i, g := range groups {
+			if taken[i] == len(g) {
+				continue
+			}
+			deficit := float64(len(g)*n)/float64(total) - float64(taken[i])
+			if best == -1 || deficit > bestDeficit {
+				best, bestDeficit = i, deficit
+			}
+		}
+		out = append(out, groups[best][taken[best]])
+		taken[best]++
+	}
+	return out
+}
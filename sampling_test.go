@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+)
+
+func testCallsigns() []CallsignOutput {
+	var cs []CallsignOutput
+	add := func(airline string, n int, dests ...string) {
+		for i := 0; i < n; i++ {
+			cs = append(cs, CallsignOutput{
+				Airline:      airline,
+				ICAOCallsign: fmt.Sprintf("%v%v", airline, 100+i),
+				Destination:  dests[i%len(dests)],
+			})
+		}
+	}
+	add("DAL", 12, "KATL", "KLAX")
+	add("JBU", 6, "KBOS", "KFLL", "KLAX")
+	add("AAL", 3, "KDFW")
+	add("UAL", 1, "KORD")
+	return cs
+}
+
+func callsignNames(cs []CallsignOutput) []string {
+	var names []string
+	for _, c := range cs {
+		names = append(names, c.ICAOCallsign)
+	}
+	return names
+}
+
+func TestSampleCallsigns(t *testing.T) {
+	callsigns := testCallsigns()
+	original := callsignNames(callsigns)
+	strategies := []string{"", samplingFirst, samplingRandom, samplingAirline, samplingDestination, samplingProportional}
+	for _, strategy := range strategies {
+		got := callsignNames(sampleCallsigns(callsigns, strategy, airportRand(1, "KJFK")))
+
+		// Every strategy only reorders the callsigns, and leaves the
+		// original alone.
+		sorted := slices.Clone(got)
+		slices.Sort(sorted)
+		want := slices.Clone(original)
+		slices.Sort(want)
+		if !slices.Equal(sorted, want) {
+			t.Errorf("%q: got %v, which isn't a reordering of %v", strategy, got, original)
+		}
+		if !slices.Equal(callsignNames(callsigns), original) {
+			t.Errorf("%q: the callsigns passed in were reordered", strategy)
+		}
+
+		// The same seed and airport give the same order.
+		again := callsignNames(sampleCallsigns(callsigns, strategy, airportRand(1, "KJFK")))
+		if !slices.Equal(got, again) {
+			t.Errorf("%q: seed 1 gave %v and then %v", strategy, got, again)
+		}
+	}
+
+	if got := callsignNames(sampleCallsigns(callsigns, samplingFirst, airportRand(1, "KJFK"))); !slices.Equal(got, original) {
+		t.Errorf("first: got %v, want %v", got, original)
+	}
+	a := callsignNames(sampleCallsigns(callsigns, samplingRandom, airportRand(1, "KJFK")))
+	if b := callsignNames(sampleCallsigns(callsigns, samplingRandom, airportRand(2, "KJFK"))); slices.Equal(a, b) {
+		t.Errorf("random: seeds 1 and 2 both gave %v", a)
+	}
+	if b := callsignNames(sampleCallsigns(callsigns, samplingRandom, airportRand(1, "KLGA"))); slices.Equal(a, b) {
+		t.Errorf("random: KJFK and KLGA both gave %v", a)
+	}
+}
+
+func TestSamplingBalance(t *testing.T) {
+	callsigns := testCallsigns()
+	airline := func(c CallsignOutput) string { return c.Airline }
+	destination := func(c CallsignOutput) string { return c.Destination }
+
+	tests := []struct {
+		strategy string
+		key      func(CallsignOutput) string
+		// check is given how many of a group have been taken after n
+		// callsigns, and how many it has in all.
+		check func(taken, total, n int) bool
+	}{
+		// Round robin: no group gets ahead of another that still has
+		// callsigns left.
+		{samplingAirline, airline, nil},
+		{samplingDestination, destination, nil},
+		// Every prefix has each airline within one of its share.
+		{samplingProportional, airline, func(taken, total, n int) bool {
+			return math.Abs(float64(taken)-float64(total*n)/float64(len(callsigns))) <= 1
+		}},
+	}
+	for _, test := range tests {
+		for seed := int64(0); seed < 5; seed++ {
+			got := sampleCallsigns(callsigns, test.strategy, airportRand(seed, "KJFK"))
+			groupTotals := make(map[string]int)
+			for _, c := range callsigns {
+				groupTotals[test.key(c)]++
+			}
+			taken := make(map[string]int)
+			for n, c := range got {
+				taken[test.key(c)]++
+				for _, k := range sortedKeys(groupTotals) {
+					if test.check != nil {
+						if !test.check(taken[k], groupTotals[k], n+1) {
+							t.Errorf("%q, seed %v: %v has %v of %v after %v callsigns", test.strategy, seed,
+								k, taken[k], groupTotals[k], n+1)
+						}
+					} else if taken[k] < groupTotals[k] && taken[test.key(c)] > taken[k]+1 {
+						t.Errorf("%q, seed %v: %v is at %v while %v is at %v", test.strategy, seed,
+							test.key(c), taken[test.key(c)], k, taken[k])
+					}
+				}
+			}
+		}
+	}
+}
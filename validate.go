@@ -0,0 +1,483 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+)
+
+// The schemas that resource files are checked against. They mirror the
+// types the fetcher decodes into but are strict about field types so that
+// validate can report exactly where a file goes wrong.
+
+type scratchpadRulesSchema struct {
+	Rules *[]struct {
+		Exit                *string `json:"exit"`
+		Scratchpad          *string `json:"scratchpad"`
+		SecondaryScratchpad *string `json:"secondary_scratchpad"`
+	} `json:"rules"`
+}
+
+type exitExceptionsSchema []struct {
+	FoundExit  *string   `json:"found_exit"`
+	ActualExit *[]string `json:"actual_exit"`
+}
+
+type openscopeAirlinesSchema struct {
+	Airlines *[]struct {
+		ICAO     *string `json:"icao"`
+		Name     *string `json:"name"`
+		Callsign *struct {
+			Name            *string  `json:"name"`
+			CallsignFormats []string `json:"callsignFormats"`
+			Length          int      `json:"length"`
+		} `json:"callsign"`
+		Fleets map[string][][2]any `json:"fleets"`
+	} `json:"airlines"`
+}
+
+// decodeStrict unmarshals b into v, rejecting fields that v doesn't declare
+// and any trailing data after the top-level value.
+func decodeStrict(b []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return errors.New("unexpected data after top-level value")
+	}
+	return nil
+}
+
+type validator struct {
+	problems int
+	warnings int
+}
+
+func (v *validator) errorf(file, format string, args ...any) {
+	v.problems++
+	fmt.Printf("%v: error: %v\n", file, fmt.Sprintf(format, args...))
+}
+
+func (v *validator) warnf(file, format string, args ...any) {
+	v.warnings++
+	fmt.Printf("%v: warning: %v\n", file, fmt.Sprintf(format, args...))
+}
+
+// runValidate implements the validate subcommand. It returns the process
+// exit code: 0 if every resource file is usable, 1 otherwise.
+func runValidate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	dir := flags.String("resources", "", "resources folder to validate")
+	configFlag := flags.String("config", "", "facility configuration file to validate")
+	viceFlag := flags.String("vice-resources", "", "vice installation to read the airline database from")
+	flags.Parse(args)
+
+	v := &validator{}
+	cfg := defaultConfig()
+	if *configFlag != "" {
+		if b, err := os.ReadFile(*configFlag); err != nil {
+			v.errorf(*configFlag, "%v", err)
+		} else {
+			if err := decodeStrict(b, &cfg); err != nil {
+				v.errorf(*configFlag, "%v", err)
+			}
+			if _, _, err := cfg.window(); err != nil {
+				v.errorf(*configFlag, "%v", err)
+			}
+			if err := validAltitudeCheck(cfg.AltitudeCheck); err != nil {
+				v.errorf(*configFlag, "%v", err)
+			}
+			if err := cfg.checkOnly(); err != nil {
+				v.errorf(*configFlag, "%v", err)
+			}
+			if err := checkCornerPosts(cfg.CornerPosts); err != nil {
+				v.errorf(*configFlag, "%v", err)
+			}
+			if err := validSampling(cfg.Sampling); err != nil {
+				v.errorf(*configFlag, "%v", err)
+			}
+			if _, _, err := cfg.Distance.limits(); err != nil {
+				v.errorf(*configFlag, "distance: %v", err)
+			}
+			if err := cfg.Routes.check(); err != nil {
+				v.errorf(*configFlag, "routes: %v", err)
+			}
+			if err := cfg.Wake.check(); err != nil {
+				v.errorf(*configFlag, "wake: %v", err)
+			}
+			if _, err := cfg.CrossCheck.tolerance(); err != nil {
+				v.errorf(*configFlag, "cross_check: %v", err)
+			}
+		}
+	}
+	if *dir != "" {
+		cfg.Resources = *dir
+	}
+	if *viceFlag != "" {
+		cfg.ViceResources = *viceFlag
+	}
+	if err := initResources(cfg.Resources, cfg.ViceResources); err != nil {
+		v.errorf("resources", "%v", err)
+		fmt.Printf("%v error(s), %v warning(s)\n", v.problems, v.warnings)
+		return 1
+	}
+
+	// Inline rules in the config are checked the same way as the files
+	// they replace.
+	var exits map[string][]string
+	if cfg.ExitExceptions != nil {
+		b, _ := json.Marshal(cfg.ExitExceptions)
+		exits = v.validateExitExceptions(*configFlag+" (exit_exceptions)", b)
+	} else {
+		path := resourceName("exit-exeptions.json")
+		if b, ok := v.readResource("exit-exeptions.json", true); ok {
+			exits = v.validateExitExceptions(path, b)
+		}
+	}
+	if cfg.ScratchpadRules != nil {
+		b, _ := json.Marshal(cfg.ScratchpadRules)
+		v.validateScratchpadRules(*configFlag+" (scratchpad_rules)", b, exits)
+	} else {
+		path := resourceName("scratchpad-rules.json")
+		if b, ok := v.readResource("scratchpad-rules.json", true); ok {
+			v.validateScratchpadRules(path, b, exits)
+		}
+	}
+	if cfg.Filters != nil {
+		b, _ := json.Marshal(cfg.Filters)
+		v.validateCallsignFilters(*configFlag+" (filters)", b)
+	} else {
+		path := resourceName("callsign-filters.json")
+		if b, ok := v.readResource("callsign-filters.json", true); ok {
+			v.validateCallsignFilters(path, b)
+		}
+	}
+	if b, ok := v.readResource("airports.json", true); ok {
+		v.validateAirports(resourceName("airports.json"), b)
+		if airports, err := loadAirports("airports.json"); err == nil && len(cfg.airports()) > 0 {
+			if _, unknown, err := resolveAirports(cfg.airports(), airports); err != nil {
+				v.errorf(*configFlag, "%v", err)
+			} else {
+				for _, icao := range unknown {
+					if cfg.AllowUnknownAirports {
+						v.warnf(*configFlag, "%v isn't in airports.json, so its location isn't known", icao)
+					} else {
+						v.errorf(*configFlag, "%v isn't in airports.json; add it, or set allow_unknown_airports", icao)
+					}
+				}
+			}
+		}
+	}
+	if b, ok := v.readResource("type-equivalents.json", true); ok {
+		v.validateTypeEquivalents(resourceName("type-equivalents.json"), b)
+	}
+	if b, ok := v.readResource("aircraft-types.json", true); ok {
+		v.validateAircraftTypes(resourceName("aircraft-types.json"), b)
+	}
+	if cfg.GA {
+		if b, ok := v.readResource("registry.json", true); ok {
+			v.validateRegistry(resourceName("registry.json"), b)
+		}
+	}
+	if b, name, err := loadAirlineDatabase(); err != nil {
+		v.errorf(name, "%v", err)
+	} else {
+		v.validateOpenscopeAirlines(name, b)
+	}
+
+	fmt.Printf("%v error(s), %v warning(s)\n", v.problems, v.warnings)
+	if v.problems > 0 {
+		return 1
+	}
+	return 0
+}
+
+// readResource reads a resource file for validation.
+func (v *validator) readResource(path string, optional bool) ([]byte, bool) {
+	b, err := LoadResource(path)
+	if errors.Is(err, fs.ErrNotExist) && optional {
+		fmt.Printf("%v: not found, skipping\n", resourceName(path))
+		return nil, false
+	} else if err != nil {
+		v.errorf(resourceName(path), "%v", err)
+		return nil, false
+	}
+	return b, true
+}
+
+// validateExitExceptions checks exit-exeptions.json and returns the set of
+// exits that may be produced after exceptions are applied, keyed by the
+// found exit they came from.
+func (v *validator) validateExitExceptions(path string, b []byte) map[string][]string {
+	var schema exitExceptionsSchema
+	if err := decodeStrict(b, &schema); err != nil {
+		v.errorf(path, "%v", err)
+		return nil
+	}
+
+	exits := make(map[string][]string)
+	for i, e := range schema {
+		if e.FoundExit == nil || *e.FoundExit == "" {
+			v.errorf(path, "entry %v: missing \"found_exit\"", i)
+			continue
+		}
+		found := *e.FoundExit
+		if e.ActualExit == nil || len(*e.ActualExit) == 0 {
+			v.warnf(path, "entry %v (%v): no \"actual_exit\" fixes; it will never fire", i, found)
+			continue
+		}
+		if prev, ok := exits[found]; ok {
+			v.warnf(path, "entry %v: %v already has an exception (%v); the first one with a fix in the route is used",
+				i, found, strings.Join(prev, ", "))
+		}
+		for _, fix := range *e.ActualExit {
+			switch {
+			case fix == "":
+				v.errorf(path, "entry %v (%v): empty fix in \"actual_exit\"", i, found)
+			case fix == found:
+				v.warnf(path, "entry %v (%v): \"actual_exit\" contains the found exit itself", i, found)
+			case slices.Contains(exits[found], fix):
+				v.warnf(path, "entry %v (%v): %v is listed more than once", i, found, fix)
+			default:
+				exits[found] = append(exits[found], fix)
+			}
+		}
+	}
+
+	// An actual exit that is itself a found exit would need a second pass
+	// to resolve, which the fetcher doesn't do.
+	for _, found := range sortedKeys(exits) {
+		for _, fix := range exits[found] {
+			if _, ok := exits[fix]; ok {
+				v.errorf(path, "%v maps to %v, which is itself a found exit; exceptions aren't chained", found, fix)
+			}
+		}
+	}
+	return exits
+}
+
+// validateScratchpadRules checks scratchpad-rules.json. exits holds the
+// exit exceptions so that rules shadowed by them can be flagged.
+func (v *validator) validateScratchpadRules(path string, b []byte, exits map[string][]string) {
+	var schema scratchpadRulesSchema
+	if err := decodeStrict(b, &schema); err != nil {
+		v.errorf(path, "%v", err)
+		return
+	}
+	if schema.Rules == nil {
+		v.errorf(path, "missing \"rules\" array")
+		return
+	}
+
+	seen := make(map[string]int)
+	for i, rule := range *schema.Rules {
+		if rule.Exit == nil || *rule.Exit == "" {
+			v.errorf(path, "rule %v: missing \"exit\"; it will never fire", i)
+			continue
+		}
+		exit := *rule.Exit
+		if (rule.Scratchpad == nil || *rule.Scratchpad == "") &&
+			(rule.SecondaryScratchpad == nil || *rule.SecondaryScratchpad == "") {
+			v.warnf(path, "rule %v (%v): neither \"scratchpad\" nor \"secondary_scratchpad\" is set", i, exit)
+		}
+		if j, ok := seen[exit]; ok {
+			v.warnf(path, "rule %v (%v): duplicates rule %v; later values override earlier ones", i, exit, j)
+		} else {
+			seen[exit] = i
+		}
+		if actual, ok := exits[exit]; ok {
+			v.warnf(path, "rule %v (%v): exit is replaced by an exception when the route contains %v",
+				i, exit, strings.Join(actual, " or "))
+		}
+	}
+}
+
+func (v *validator) validateOpenscopeAirlines(path string, b []byte) {
+	var schema openscopeAirlinesSchema
+	if err := decodeStrict(b, &schema); err != nil {
+		v.errorf(path, "%v", err)
+		return
+	}
+	if schema.Airlines == nil {
+		v.errorf(path, "missing \"airlines\" array")
+		return
+	}
+
+	seen := make(map[string]int)
+	for i, al := range *schema.Airlines {
+		if al.ICAO == nil || *al.ICAO == "" {
+			v.errorf(path, "airline %v: missing \"icao\"", i)
+			continue
+		}
+		icao := strings.ToUpper(*al.ICAO)
+		if j, ok := seen[icao]; ok {
+			v.errorf(path, "airline %v (%v): duplicates airline %v", i, icao, j)
+		}
+		seen[icao] = i
+		if al.Callsign == nil || al.Callsign.Name == nil {
+			v.warnf(path, "airline %v (%v): missing callsign name", i, icao)
+		}
+		if len(al.Fleets) == 0 {
+			v.errorf(path, "airline %v (%v): no fleets", i, icao)
+		}
+		for name, fleet := range al.Fleets {
+			for k, ac := range fleet {
+				if t, ok := ac[0].(string); !ok || t == "" {
+					v.errorf(path, "airline %v (%v): fleet %q entry %v: aircraft type must be a string", i, icao, name, k)
+				}
+				if n, ok := ac[1].(float64); !ok || n <= 0 {
+					v.errorf(path, "airline %v (%v): fleet %q entry %v: count must be a positive number", i, icao, name, k)
+				}
+			}
+		}
+	}
+}
+
+func (v *validator) validateCallsignFilters(path string, b []byte) {
+	var filters CallsignFilters
+	if err := decodeStrict(b, &filters); err != nil {
+		v.errorf(path, "%v", err)
+		return
+	}
+	if err := filters.compile(); err != nil {
+		v.errorf(path, "%v", err)
+	}
+
+	if filters.AllowOnly && len(filters.Include.Airlines) == 0 && len(filters.Include.Patterns) == 0 &&
+		len(filters.Include.Categories) == 0 {
+		v.errorf(path, "\"allow_only\" is set but nothing is included; every flight will be dropped")
+	}
+	for _, al := range filters.Exclude.Airlines {
+		if slices.Contains(filters.Include.Airlines, al) {
+			v.warnf(path, "%v is both included and excluded; including wins", al)
+		}
+	}
+	if filters.AllowOnly && len(filters.Exclude.Airlines)+len(filters.Exclude.Patterns)+len(filters.Exclude.Categories) > 0 {
+		v.warnf(path, "exclusions are ignored when \"allow_only\" is set")
+	}
+	for cat := range filters.Categories {
+		if _, ok := callsignCategories[cat]; ok {
+			continue
+		}
+		if !slices.Contains(filters.Include.Categories, cat) && !slices.Contains(filters.Exclude.Categories, cat) {
+			v.warnf(path, "category %q is defined but never used", cat)
+		}
+	}
+	for _, airlines := range [][]string{filters.Include.Airlines, filters.Exclude.Airlines} {
+		seen := make(map[string]bool)
+		for _, al := range airlines {
+			if seen[al] {
+				v.warnf(path, "%v is listed more than once", al)
+			}
+			seen[al] = true
+			if al != strings.ToUpper(al) || len(al) != 3 {
+				v.warnf(path, "%q doesn't look like an airline ICAO code", al)
+			}
+		}
+	}
+}
+
+func (v *validator) validateRegistry(path string, b []byte) {
+	var reg map[string]string
+	if err := decodeStrict(b, &reg); err != nil {
+		v.errorf(path, "%v", err)
+		return
+	}
+	for r, t := range reg {
+		if t == "" {
+			v.errorf(path, "%v: missing aircraft type", r)
+		} else if len(t) < 2 || len(t) > 4 {
+			v.warnf(path, "%v: %q doesn't look like an ICAO aircraft type", r, t)
+		}
+	}
+}
+
+func (v *validator) validateTypeEquivalents(path string, b []byte) {
+	var eq TypeEquivalents
+	if err := decodeStrict(b, &eq); err != nil {
+		v.errorf(path, "%v", err)
+		return
+	}
+	seen := make(map[string]int)
+	for i, family := range eq.Families {
+		if len(family) < 2 {
+			v.warnf(path, "family %v has fewer than two types, so it will never be used", i)
+		}
+		for _, t := range family {
+			if t != strings.ToUpper(t) || len(t) < 2 || len(t) > 4 {
+				v.errorf(path, "family %v: %q isn't an ICAO aircraft type designator", i, t)
+			}
+			if j, ok := seen[t]; ok && j != i {
+				v.warnf(path, "%v is in families %v and %v", t, j, i)
+			}
+			seen[t] = i
+		}
+	}
+}
+
+func (v *validator) validateAircraftTypes(path string, b []byte) {
+	var types map[string]AircraftTypeInfo
+	if err := decodeStrict(b, &types); err != nil {
+		v.errorf(path, "%v", err)
+		return
+	}
+	for _, t := range sortedKeys(types) {
+		info := types[t]
+		if t != strings.ToUpper(t) || len(t) < 2 || len(t) > 4 {
+			v.errorf(path, "%q isn't an ICAO aircraft type designator", t)
+		}
+		if info.Wake != "" && !slices.Contains(legacyWakeCategories, info.Wake) {
+			v.errorf(path, "%v: %q isn't a wake category", t, info.Wake)
+		}
+		if info.RECAT != "" && !slices.Contains(recatCategories, info.RECAT) {
+			v.errorf(path, "%v: %q isn't a RECAT category", t, info.RECAT)
+		}
+		if info.Engine != "" && !slices.Contains(engineClasses, info.Engine) {
+			v.errorf(path, "%v: %q isn't an engine class", t, info.Engine)
+		}
+		if info == (AircraftTypeInfo{}) {
+			v.warnf(path, "%v says nothing about the type", t)
+		}
+	}
+}
+
+func (v *validator) validateAirports(path string, b []byte) {
+	var airports map[string]Airport
+	if err := decodeStrict(b, &airports); err != nil {
+		v.errorf(path, "%v", err)
+		return
+	}
+	iata := make(map[string]string)
+	for _, icao := range sortedKeys(airports) {
+		ap := airports[icao]
+		if len(icao) != 4 || icao != strings.ToUpper(icao) {
+			v.errorf(path, "%q isn't an ICAO airport code", icao)
+		}
+		if ap.Lat < -90 || ap.Lat > 90 || ap.Lon < -180 || ap.Lon > 180 {
+			v.errorf(path, "%v: %v, %v isn't a valid location", icao, ap.Lat, ap.Lon)
+		}
+		if ap.IATA != "" {
+			if len(ap.IATA) != 3 || ap.IATA != strings.ToUpper(ap.IATA) {
+				v.errorf(path, "%v: %q isn't an IATA airport code", icao, ap.IATA)
+			} else if other, ok := iata[ap.IATA]; ok {
+				v.warnf(path, "%v and %v are both %v; %v will be used for it", other, icao, ap.IATA, other)
+			} else {
+				iata[ap.IATA] = icao
+			}
+		}
+		if ap.Name == "" {
+			v.warnf(path, "%v has no name", icao)
+		}
+		if len(ap.Country) != 2 {
+			v.warnf(path, "%v: %q isn't an ISO country code", icao, ap.Country)
+		}
+	}
+}
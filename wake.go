@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Engine classes departures are tagged with.
+const (
+	engineJet       = "jet"
+	engineTurboprop = "turboprop"
+	enginePiston    = "piston"
+)
+
+// Wake turbulence categories: the legacy ones (super, heavy, B757, large
+// and small) and RECAT's A (the A380) to F (the lightest).
+var (
+	legacyWakeCategories = []string{"J", "H", "B", "L", "S"}
+	recatCategories      = []string{"A", "B", "C", "D", "E", "F"}
+	engineClasses        = []string{engineJet, engineTurboprop, enginePiston}
+)
+
+// AircraftTypeInfo is what aircraft-types.json says about a type.
+type AircraftTypeInfo struct {
+	Wake   string `json:"wake,omitempty"`
+	RECAT  string `json:"recat,omitempty"`
+	Engine string `json:"engine,omitempty"`
+}
+
+// loadAircraftTypes reads the aircraft type table at path in the resources
+// folder, keyed by ICAO type designator.
+func loadAircraftTypes(path string) (map[string]AircraftTypeInfo, error) {
+	return loadJSONResource[map[string]AircraftTypeInfo](path, true)
+}
+
+// engineClass returns the engine class for FlightAware's engine type,
+// e.g. "Turboprop/Turboshaft", or "" if it's not one we know.
+func engineClass(engType string) string {
+	switch t := strings.ToLower(engType); {
+	case strings.Contains(t, "prop"), strings.Contains(t, "shaft"):
+		return engineTurboprop
+	case strings.Contains(t, "piston"):
+		return enginePiston
+	case strings.Contains(t, "jet"), strings.Contains(t, "fan"):
+		return engineJet
+	default:
+		return ""
+	}
+}
+
+// recatFromLegacy is the RECAT category most types in each legacy wake
+// category have, for types the table doesn't list.
+var recatFromLegacy = map[string]string{"J": "A", "H": "B", "B": "D", "L": "D", "S": "F"}
+
+// classify returns the wake categories and engine class of acType from what
+// FlightAware says about the aircraft, falling back to the type table for
+// whatever that doesn't settle.
+func (ft *fetcher) classify(acType string, heavy bool, engType, engCount string) AircraftTypeInfo {
+	table := ft.aircraftTypes[acType]
+	info := AircraftTypeInfo{Engine: engineClass(engType)}
+	switch engines, _ := strconv.Atoi(engCount); {
+	case heavy && table.Wake == "J":
+		// FlightAware doesn't tell supers from other heavies.
+		info.Wake = "J"
+	case heavy:
+		info.Wake = "H"
+	case engines == 1:
+		info.Wake = "S"
+	default:
+		info.Wake = table.Wake
+	}
+	if info.Engine == "" {
+		info.Engine = table.Engine
+	}
+	if info.Wake == table.Wake {
+		info.RECAT = table.RECAT
+	}
+	if info.RECAT == "" {
+		info.RECAT = recatFromLegacy[info.Wake]
+	}
+	return info
+}
+
+// WakeFilter keeps departures in certain wake categories or with certain
+// engines, e.g. only heavies or no props. An empty list allows anything.
+type WakeFilter struct {
+	Wake    []string `json:"wake,omitempty"`
+	RECAT   []string `json:"recat,omitempty"`
+	Engines []string `json:"engines,omitempty"`
+}
+
+// check returns an error for the first category or engine class that
+// doesn't exist.
+func (w WakeFilter) check() error {
+	for _, c := range w.Wake {
+		if !slices.Contains(legacyWakeCategories, strings.ToUpper(c)) {
+			return fmt.Errorf("%q isn't a wake category; use one of %v", c, strings.Join(legacyWakeCategories, ", "))
+		}
+	}
+	for _, c := range w.RECAT {
+		if !slices.Contains(recatCategories, strings.ToUpper(c)) {
+			return fmt.Errorf("%q isn't a RECAT category; use one of %v", c, strings.Join(recatCategories, ", "))
+		}
+	}
+	for _, e := range w.Engines {
+		if !slices.Contains(engineClasses, strings.ToLower(e)) {
+			return fmt.Errorf("%q isn't an engine class; use one of %v", e, strings.Join(engineClasses, ", "))
+		}
+	}
+	return nil
+}
+
+// allowed reports whether a departure of the given type should be kept.
+// One whose category isn't known is only kept if it isn't filtered on.
+func (w WakeFilter) allowed(info AircraftTypeInfo) bool {
+	match := func(list []string, v string) bool {
+		return len(list) == 0 || slices.ContainsFunc(list, func(c string) bool {
+			return strings.EqualFold(c, v)
+		})
+	}
+	return match(w.Wake, info.Wake) && match(w.RECAT, info.RECAT) && match(w.Engines, info.Engine)
+}
@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	ft := &fetcher{aircraftTypes: map[string]AircraftTypeInfo{
+		"A388": {Wake: "J", RECAT: "A", Engine: engineJet},
+		"B763": {Wake: "H", RECAT: "C", Engine: engineJet},
+		"B752": {Wake: "B", RECAT: "D", Engine: engineJet},
+		"C208": {Wake: "L", RECAT: "F", Engine: engineTurboprop},
+		"DC3":  {Wake: "L", RECAT: "E", Engine: enginePiston},
+	}}
+	tests := []struct {
+		acType   string
+		heavy    bool
+		engType  string
+		engCount string
+		want     AircraftTypeInfo
+	}{
+		// What FlightAware says comes first.
+		{"A388", true, "Jet", "4", AircraftTypeInfo{"J", "A", engineJet}},
+		{"B763", true, "Jet", "2", AircraftTypeInfo{"H", "C", engineJet}},
+		{"C208", false, "Turboprop/Turboshaft", "1", AircraftTypeInfo{"S", "F", engineTurboprop}},
+		{"B752", true, "Jet", "2", AircraftTypeInfo{"H", "B", engineJet}},
+		// The table fills in what FlightAware doesn't settle.
+		{"B752", false, "Jet", "2", AircraftTypeInfo{"B", "D", engineJet}},
+		{"DC3", false, "", "2", AircraftTypeInfo{"L", "E", enginePiston}},
+		{"B763", false, "", "", AircraftTypeInfo{"H", "C", engineJet}},
+		// Types the table doesn't know get RECAT from the legacy category.
+		{"B77W", true, "Jet", "2", AircraftTypeInfo{"H", "B", engineJet}},
+		{"C172", false, "Piston", "1", AircraftTypeInfo{"S", "F", enginePiston}},
+		{"E75L", false, "Jet", "2", AircraftTypeInfo{"", "", engineJet}},
+		{"ZZZZ", false, "", "", AircraftTypeInfo{}},
+	}
+	for _, test := range tests {
+		got := ft.classify(test.acType, test.heavy, test.engType, test.engCount)
+		if got != test.want {
+			t.Errorf("classify(%v, %v, %q, %q) = %+v; want %+v", test.acType, test.heavy, test.engType,
+				test.engCount, got, test.want)
+		}
+	}
+}